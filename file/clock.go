@@ -0,0 +1,223 @@
+package file
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time for timestamps of a FS.
+type Clock interface {
+	Now() time.Time
+}
+
+// Sleeper is implemented by clocks which control sleeping (ex: FakeClock).
+// SleepContext returns ctx.Err() if ctx is done before d elapsed.
+type Sleeper interface {
+	SleepContext(ctx context.Context, d time.Duration) error
+}
+
+// FakeClock is a Clock which only moves when told to.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer calls fn when a FakeClock reaches at.
+type fakeTimer struct {
+	at time.Time
+	fn func()
+}
+
+// NewFakeClock creates a new FakeClock starting at t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Sleep advances the clock by d without sleeping.
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// SleepContext advances the clock by d without sleeping. It stops at the
+// time ctx is done (see WithTimeout) and returns ctx.Err().
+func (c *FakeClock) SleepContext(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	end := c.Now().Add(d)
+	for {
+		next, ok := c.nextTimer(end)
+		if !ok {
+			break
+		}
+		c.Set(next)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	c.Set(end)
+	return ctx.Err()
+}
+
+// nextTimer returns the time of the next timer up to end.
+func (c *FakeClock) nextTimer(end time.Time) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.timers) == 0 || c.timers[0].at.After(end) {
+		return time.Time{}, false
+	}
+	return c.timers[0].at, true
+}
+
+// Set sets the clock to t and fires the timers due.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	due := []*fakeTimer{}
+	for len(c.timers) > 0 && !c.timers[0].at.After(t) {
+		due = append(due, c.timers[0])
+		c.timers = c.timers[1:]
+	}
+	c.mu.Unlock()
+	for _, timer := range due {
+		timer.fn()
+	}
+}
+
+// afterFunc calls fn when the clock reaches at.
+func (c *FakeClock) afterFunc(at time.Time, fn func()) {
+	c.mu.Lock()
+	if at.After(c.now) {
+		c.timers = append(c.timers, &fakeTimer{at: at, fn: fn})
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+	fn()
+}
+
+// WithTimeout is like context.WithTimeout, but the deadline is measured by
+// the clock.
+func (c *FakeClock) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return c.WithDeadline(parent, c.Now().Add(d))
+}
+
+// WithDeadline is like context.WithDeadline, but the deadline is measured by
+// the clock.
+func (c *FakeClock) WithDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	ctx := &fakeContext{Context: parent, deadline: deadline, done: make(chan struct{})}
+	c.afterFunc(deadline, func() { ctx.cancel(context.DeadlineExceeded) })
+	if parent.Done() != nil {
+		go func() {
+			select {
+			case <-parent.Done():
+				ctx.cancel(parent.Err())
+			case <-ctx.done:
+			}
+		}()
+	}
+	return ctx, func() { ctx.cancel(context.Canceled) }
+}
+
+// fakeContext is a context with a deadline of a FakeClock.
+type fakeContext struct {
+	context.Context
+	deadline time.Time
+	mu       sync.Mutex
+	done     chan struct{}
+	err      error
+}
+
+func (ctx *fakeContext) Deadline() (time.Time, bool) { return ctx.deadline, true }
+
+func (ctx *fakeContext) Done() <-chan struct{} { return ctx.done }
+
+func (ctx *fakeContext) Err() error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.err
+}
+
+func (ctx *fakeContext) cancel(err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.err == nil {
+		ctx.err = err
+		close(ctx.done)
+	}
+}
+
+// WithClock is an option to set the clock used for timestamps.
+func WithClock(c Clock) Option {
+	return func(fs *FS) {
+		fs.clock = c
+	}
+}
+
+// SetClock sets the clock used for timestamps. Modification times of files
+// added with an offset (FModTimeOffset) which did not change since are
+// moved to the new clock.
+func (fs *FS) SetClock(c Clock) {
+	fs.clock = c
+	for fi := range fs.relTimes {
+		fi.FModTime = fs.now().Add(fi.FModTimeOffset)
+	}
+}
+
+// Now returns the current time of the FS clock.
+func (fs *FS) Now() time.Time {
+	return fs.now()
+}
+
+func (fs *FS) now() time.Time {
+	if fs.clock != nil {
+		return fs.clock.Now()
+	}
+	return time.Now()
+}
+
+// sleep sleeps for d using the clock if it is a Sleeper. It returns
+// ctx.Err() if ctx is done before.
+func (fs *FS) sleep(ctx context.Context, d time.Duration) error {
+	if s, ok := fs.clock.(Sleeper); ok {
+		return s.SleepContext(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// touch sets the modification and change time of fi.
+func (fs *FS) touch(fi *FileInfo) {
+	now := fs.now()
+	fi.FModTime = now
+	fi.FCTime = now
+	delete(fs.relTimes, fi)
+}
+
+// touchAccess sets the access time of fi after its data or entries were
+// read.
+func (fs *FS) touchAccess(fi *FileInfo) {
+	fi.FATime = fs.now()
+}
@@ -0,0 +1,136 @@
+package file
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTime = time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC)
+
+func TestFakeClock(t *testing.T) {
+	c := NewFakeClock(testTime)
+	assert.Equal(t, testTime, c.Now())
+	c.Advance(time.Hour)
+	assert.Equal(t, testTime.Add(time.Hour), c.Now())
+	c.Set(testTime)
+	assert.Equal(t, testTime, c.Now())
+}
+
+func TestFS_timestamps(t *testing.T) {
+	tests := []struct {
+		name      string
+		op        func(fs *FS) error
+		path      string
+		wantMTime bool
+		wantCTime bool
+		wantATime bool
+	}{
+		{
+			name:      "create",
+			op:        func(fs *FS) error { return fs.WriteFile("/home/new", []byte("new"), 0644) },
+			path:      "/home/new",
+			wantMTime: true, wantCTime: true, wantATime: true,
+		},
+		{
+			name:      "createUpdatesParent",
+			op:        func(fs *FS) error { return fs.WriteFile("/home/new", []byte("new"), 0644) },
+			path:      "/home",
+			wantMTime: true, wantCTime: true,
+		},
+		{
+			name:      "write",
+			op:        func(fs *FS) error { return fs.WriteFile("/home/file1", []byte("new"), 0644) },
+			path:      "/home/file1",
+			wantMTime: true, wantCTime: true,
+		},
+		{
+			name:      "truncate",
+			op:        func(fs *FS) error { return fs.Truncate("/home/file1", 1) },
+			path:      "/home/file1",
+			wantMTime: true, wantCTime: true,
+		},
+		{
+			name:      "chmod",
+			op:        func(fs *FS) error { return fs.Chmod("/home/file1", 0600) },
+			path:      "/home/file1",
+			wantCTime: true,
+		},
+		{
+			name:      "readFile",
+			op:        func(fs *FS) error { _, err := fs.ReadFile("/home/file1"); return err },
+			path:      "/home/file1",
+			wantATime: true,
+		},
+		{
+			name: "read",
+			op: func(fs *FS) error {
+				f, err := fs.Open("/home/file1")
+				if err != nil {
+					return err
+				}
+				_, err = f.Read(make([]byte, 1))
+				return err
+			},
+			path:      "/home/file1",
+			wantATime: true,
+		},
+		{
+			name:      "readDir",
+			op:        func(fs *FS) error { _, err := fs.ReadDir("/home"); return err },
+			path:      "/home",
+			wantATime: true,
+		},
+		{
+			name: "stat",
+			op:   func(fs *FS) error { _, err := fs.Stat("/home/file1"); return err },
+			path: "/home/file1",
+		},
+		{
+			name:      "rename",
+			op:        func(fs *FS) error { return fs.Rename("/home/file1", "/home/file2") },
+			path:      "/home/file2",
+			wantCTime: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(testTime)
+			fs := newFS(t, map[string]*FileInfo{
+				"/home":       {FIsDir: true},
+				"/home/file1": {Data: []byte("file1"), FModTimeOffset: -2 * time.Hour},
+			}, WithClock(clock))
+			clock.Advance(time.Minute)
+			assert.NoError(t, tt.op(fs))
+
+			fi := fs.FileInfo(tt.path).(*FileInfo)
+			now := clock.Now()
+			assert.Equal(t, tt.wantMTime, fi.ModTime().Equal(now), "mtime %v", fi.ModTime())
+			assert.Equal(t, tt.wantCTime, fi.ChangeTime().Equal(now), "ctime %v", fi.ChangeTime())
+			assert.Equal(t, tt.wantATime, fi.AccessTime().Equal(now), "atime %v", fi.AccessTime())
+		})
+	}
+}
+
+func TestFS_relativeModTime(t *testing.T) {
+	clock := NewFakeClock(testTime)
+	fs := newFS(t, map[string]*FileInfo{"/old": {FModTimeOffset: -2 * time.Hour}}, WithClock(clock))
+	fi, err := fs.Stat("/old")
+	assert.NoError(t, err)
+	assert.Equal(t, testTime.Add(-2*time.Hour), fi.ModTime())
+	assert.Equal(t, os.FileMode(0), fi.Mode())
+}
+
+func TestFS_SetClock(t *testing.T) {
+	fs := newFS(t, map[string]*FileInfo{
+		"/old":     {FModTimeOffset: -2 * time.Hour},
+		"/written": {FModTimeOffset: -2 * time.Hour},
+	})
+	assert.NoError(t, fs.WriteFile("/written", nil, 0644))
+	written := fs.FileInfo("/written").ModTime()
+	fs.SetClock(NewFakeClock(testTime))
+	assert.Equal(t, testTime.Add(-2*time.Hour), fs.FileInfo("/old").ModTime())
+	assert.Equal(t, written, fs.FileInfo("/written").ModTime())
+}
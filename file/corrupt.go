@@ -0,0 +1,50 @@
+package file
+
+import "math/rand"
+
+// Corruption corrupts data read from a file using the random source of a
+// rule (see Rule.Corrupt). It may modify data.
+type Corruption func(data []byte, r *rand.Rand) []byte
+
+// BitFlips flips n bits at random positions.
+func BitFlips(n int) Corruption {
+	return func(data []byte, r *rand.Rand) []byte {
+		if len(data) == 0 {
+			return data
+		}
+		for i := 0; i < n; i++ {
+			bit := r.Intn(len(data) * 8)
+			data[bit/8] ^= 1 << uint(bit%8)
+		}
+		return data
+	}
+}
+
+// Truncated truncates data to size bytes.
+func Truncated(size int) Corruption {
+	return func(data []byte, r *rand.Rand) []byte {
+		if size < len(data) {
+			return data[:size]
+		}
+		return data
+	}
+}
+
+// Zeroed zeroes n bytes starting at offset.
+func Zeroed(offset int, n int) Corruption {
+	return func(data []byte, r *rand.Rand) []byte {
+		for i := offset; i < offset+n && i < len(data); i++ {
+			data[i] = 0
+		}
+		return data
+	}
+}
+
+// Garbage appends n random bytes.
+func Garbage(n int) Corruption {
+	return func(data []byte, r *rand.Rand) []byte {
+		garbage := make([]byte, n)
+		r.Read(garbage)
+		return append(data, garbage...)
+	}
+}
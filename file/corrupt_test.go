@@ -0,0 +1,101 @@
+package file
+
+import (
+	"io/ioutil"
+	"math/bits"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorruption(t *testing.T) {
+	tests := []struct {
+		name       string
+		corruption Corruption
+		want       []byte
+	}{
+		{name: "truncated", corruption: Truncated(3), want: []byte("012")},
+		{name: "truncatedLonger", corruption: Truncated(20), want: []byte("0123456789")},
+		{name: "zeroed", corruption: Zeroed(2, 3), want: []byte("01\x00\x00\x0056789")},
+		{name: "zeroedEnd", corruption: Zeroed(8, 5), want: []byte("01234567\x00\x00")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.corruption([]byte("0123456789"), rand.New(rand.NewSource(1)))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCorruption_random(t *testing.T) {
+	data := []byte("0123456789")
+	flip := func(seed int64) []byte {
+		return BitFlips(3)(append([]byte{}, data...), rand.New(rand.NewSource(seed)))
+	}
+	assert.Equal(t, flip(1), flip(1))
+	diff := 0
+	for i, b := range flip(1) {
+		diff += bits.OnesCount8(b ^ data[i])
+	}
+	assert.True(t, diff > 0 && diff <= 3)
+
+	got := Garbage(4)(append([]byte{}, data...), rand.New(rand.NewSource(1)))
+	assert.Len(t, got, 14)
+	assert.Equal(t, data, got[:10])
+}
+
+func TestFS_Corrupt(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.AddRule(NewRule("flaky").Path("/home/file1").Nth(2).Corrupt(Zeroed(0, 1)))
+
+	for i, want := range []string{"file1", "\x00ile1", "file1"} {
+		data, err := fs.ReadFile("/home/file1")
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(data), "read %d", i+1)
+	}
+	assert.Equal(t, []byte("file1"), fs.PathStubs["/home/file1"].Data)
+
+	fs.AddRule(NewRule("stream").Corrupt(Zeroed(1, 1)))
+	f, _ := fs.Open("/home/dir/file2")
+	data, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "f\x00le2", string(data))
+	assert.NoError(t, fs.WriteFile("/home/dir/file2", []byte("new"), 0644))
+	assert.Equal(t, []byte("new"), fs.PathStubs["/home/dir/file2"].Data)
+}
+
+func TestFS_Corrupt_smallReads(t *testing.T) {
+	tests := []struct {
+		name       string
+		corruption Corruption
+		want       func(data []byte) bool
+	}{
+		{
+			name:       "zeroed",
+			corruption: Zeroed(1, 1),
+			want:       func(data []byte) bool { return string(data) == "f\x00le2" },
+		},
+		{
+			name:       "truncated",
+			corruption: Truncated(3),
+			want:       func(data []byte) bool { return string(data) == "fil" },
+		},
+		{
+			name:       "garbage",
+			corruption: Garbage(2),
+			want:       func(data []byte) bool { return len(data) == 7 && string(data[:5]) == "file2" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.AddRule(NewRule("stream").Corrupt(tt.corruption))
+			f, _ := fs.Open("/home/dir/file2")
+			data, err := ioutil.ReadAll(iotest.OneByteReader(f))
+			assert.NoError(t, err)
+			assert.True(t, tt.want(data), "got %q", data)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// CoverageMode determines how unused fixture paths are reported at the end
+// of a test.
+type CoverageMode int
+
+const (
+	// CoverageReport logs unused fixture paths.
+	CoverageReport CoverageMode = iota
+	// CoverageFail fails the test if there are unused fixture paths.
+	CoverageFail
+)
+
+// Coverage holds the access coverage of the declared fixture paths.
+type Coverage struct {
+	// Declared holds the sorted declared paths.
+	Declared []string
+	// Accessed counts the accesses of declared paths.
+	Accessed map[string]int
+}
+
+// Unused returns the sorted declared paths which were never accessed.
+func (c Coverage) Unused() []string {
+	retval := []string{}
+	for _, p := range c.Declared {
+		if c.Accessed[p] == 0 {
+			retval = append(retval, p)
+		}
+	}
+	return retval
+}
+
+// Ratio returns the ratio of accessed declared paths (1 for no paths).
+func (c Coverage) Ratio() float64 {
+	if len(c.Declared) == 0 {
+		return 1
+	}
+	return float64(len(c.Declared)-len(c.Unused())) / float64(len(c.Declared))
+}
+
+type coverage struct {
+	declared map[string]bool
+	accessed map[string]int
+}
+
+// WithCoverage is an option to track which declared paths are accessed (see
+// TrackCoverage).
+func WithCoverage(t testing.TB, mode CoverageMode) Option {
+	return func(fs *FS) {
+		fs.TrackCoverage(t, mode)
+	}
+}
+
+// TrackCoverage starts to track which declared paths are stat'ed, read,
+// listed or walked. Paths already added to the FS and all paths added later
+// are considered declared. Unused paths are reported to t at the end of the
+// test according to mode.
+func (fs *FS) TrackCoverage(t testing.TB, mode CoverageMode) {
+	fs.coverage = &coverage{declared: make(map[string]bool), accessed: make(map[string]int)}
+	for k := range fs.PathStubs {
+		fs.declare(k)
+	}
+	if t == nil {
+		return
+	}
+	t.Cleanup(func() {
+		unused := fs.Coverage().Unused()
+		if len(unused) == 0 {
+			return
+		}
+		msg := "fsmocker: unused fixture paths:\n\t" + strings.Join(unused, "\n\t")
+		if mode == CoverageFail {
+			t.Errorf("%s", msg)
+		} else {
+			t.Logf("%s", msg)
+		}
+	})
+}
+
+// Coverage returns the access coverage of the declared paths. It is empty if
+// coverage is not tracked.
+func (fs *FS) Coverage() Coverage {
+	c := Coverage{Declared: []string{}, Accessed: make(map[string]int)}
+	if fs.coverage == nil {
+		return c
+	}
+	for k := range fs.coverage.declared {
+		c.Declared = append(c.Declared, k)
+		c.Accessed[k] = fs.coverage.accessed[k]
+	}
+	sort.Strings(c.Declared)
+	return c
+}
+
+func (fs *FS) declare(path string) {
+	if fs.coverage != nil && path != string(os.PathSeparator) {
+		fs.coverage.declared[path] = true
+	}
+}
+
+// access marks path and its parent directories as accessed.
+func (fs *FS) access(path string) {
+	if fs.coverage == nil {
+		return
+	}
+	for {
+		fs.coverage.accessed[path]++
+		parent := filepath.Dir(path)
+		if parent == path || parent == "." {
+			return
+		}
+		path = parent
+	}
+}
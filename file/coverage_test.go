@@ -0,0 +1,86 @@
+package file
+
+import (
+	"os"
+	"testing"
+
+	"github.com/shebang-go/fsmocker/internal/faket"
+	"github.com/stretchr/testify/assert"
+)
+
+func coverageStubs() map[string]*FileInfo {
+	return map[string]*FileInfo{
+		"/home":           {FIsDir: true},
+		"/home/file1":     {},
+		"/home/dir":       {FIsDir: true},
+		"/home/dir/file2": {},
+		"/etc":            {FIsDir: true},
+		"/etc/hosts":      {},
+	}
+}
+
+func TestFS_Coverage(t *testing.T) {
+	tests := []struct {
+		name       string
+		op         func(fs *FS)
+		wantUnused []string
+	}{
+		{
+			name:       "none",
+			op:         func(fs *FS) {},
+			wantUnused: []string{"/etc", "/etc/hosts", "/home", "/home/dir", "/home/dir/file2", "/home/file1"},
+		},
+		{
+			name:       "statMarksParents",
+			op:         func(fs *FS) { fs.Stat("/home/dir/file2") },
+			wantUnused: []string{"/etc", "/etc/hosts", "/home/file1"},
+		},
+		{
+			name:       "readDirMarksEntries",
+			op:         func(fs *FS) { fs.ReadDir("/home") },
+			wantUnused: []string{"/etc", "/etc/hosts", "/home/dir/file2"},
+		},
+		{
+			name: "walk",
+			op: func(fs *FS) {
+				fs.Walk("/etc", func(path string, info os.FileInfo, err error) error { return nil })
+			},
+			wantUnused: []string{"/home", "/home/dir", "/home/dir/file2", "/home/file1"},
+		},
+		{
+			name:       "glob",
+			op:         func(fs *FS) { fs.Glob("/*/file1") },
+			wantUnused: []string{"/etc/hosts", "/home/dir", "/home/dir/file2"},
+		},
+		{
+			name:       "createdIsNotDeclared",
+			op:         func(fs *FS) { fs.WriteFile("/etc/new", nil, 0644) },
+			wantUnused: []string{"/etc/hosts", "/home", "/home/dir", "/home/dir/file2", "/home/file1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, coverageStubs(), WithCoverage(nil, CoverageReport))
+			tt.op(fs)
+			c := fs.Coverage()
+			assert.Equal(t, tt.wantUnused, c.Unused())
+			assert.Len(t, c.Declared, 6)
+		})
+	}
+}
+
+func TestFS_Coverage_cleanup(t *testing.T) {
+	ft := &faket.T{}
+	fs := newFS(t, coverageStubs(), WithCoverage(ft, CoverageFail))
+	fs.ReadFile("/home/file1")
+	ft.RunCleanups()
+	assert.Equal(t, []string{"fsmocker: unused fixture paths:\n\t/etc\n\t/etc/hosts\n\t/home/dir\n\t/home/dir/file2"}, ft.Errors())
+	assert.Equal(t, 2.0/6.0, fs.Coverage().Ratio())
+}
+
+func TestFS_Coverage_disabled(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.Stat("/home/file1")
+	assert.Empty(t, fs.Coverage().Declared)
+	assert.Equal(t, 1.0, fs.Coverage().Ratio())
+}
@@ -0,0 +1,249 @@
+package file
+
+import (
+	"bytes"
+	"math/rand"
+	"path/filepath"
+	"sort"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// DefaultPageSize is the page size used for torn writes (see CrashRandom).
+const DefaultPageSize = 4096
+
+// durability holds the state of the FS which survives a crash. Files and
+// directories are identified by their FileInfo, so renames keep them.
+type durability struct {
+	pageSize int
+	// entries holds the durable entries of directories
+	entries map[*FileInfo]map[string]*FileInfo
+	// data holds the durable data of files
+	data map[*FileInfo][]byte
+	// size holds the durable size of files, fixtures may have a size
+	// without data
+	size map[*FileInfo]int64
+}
+
+// WithDurability is an option to track which changes are durable (see
+// TrackDurability).
+func WithDurability(pageSize int) Option {
+	return func(fs *FS) {
+		fs.TrackDurability(pageSize)
+	}
+}
+
+// TrackDurability starts tracking which changes are durable. The current
+// state is durable. Afterwards, data of a file becomes durable with
+// File.Sync, entries of a directory (creations, removals and renames) become
+// durable with File.Sync of the opened directory. pageSize is used for torn
+// writes (0 means DefaultPageSize).
+func (fs *FS) TrackDurability(pageSize int) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	fs.durability = &durability{pageSize: pageSize}
+	fs.syncAll()
+}
+
+// Sync makes all changes durable (see sync(2)).
+func (fs *FS) Sync() {
+	fs.run(&Request{Op: "Sync", Path: "/"}, func(oc *OpContext) {
+		fs.syncAll()
+	})
+}
+
+func (fs *FS) syncAll() {
+	d := fs.durability
+	if d == nil {
+		return
+	}
+	d.entries = make(map[*FileInfo]map[string]*FileInfo)
+	d.data = make(map[*FileInfo][]byte)
+	d.size = make(map[*FileInfo]int64)
+	for _, fi := range fs.PathStubs {
+		fs.sync(fi)
+	}
+}
+
+// sync makes the data of a file or the entries of a directory durable.
+func (fs *FS) sync(fi *FileInfo) {
+	d := fs.durability
+	if d == nil {
+		return
+	}
+	if fi.IsDir() {
+		d.entries[fi] = fs.getDirEntries(fi.Path)
+		return
+	}
+	d.data[fi] = append([]byte{}, fi.Data...)
+	d.size[fi] = fi.FSize
+}
+
+// syncFixtures makes added files and their entries in the parent
+// directories durable.
+func (fs *FS) syncFixtures(in []*FileInfo) {
+	d := fs.durability
+	if d == nil {
+		return
+	}
+	for _, v := range in {
+		if fs.PathStubs[v.Path] != v || v.Path == "/" {
+			continue
+		}
+		if v.IsDir() {
+			if d.entries[v] == nil {
+				d.entries[v] = map[string]*FileInfo{}
+			}
+		} else {
+			fs.sync(v)
+		}
+		parent := fs.PathStubs[filepath.Dir(v.Path)]
+		if parent == nil {
+			continue
+		}
+		if d.entries[parent] == nil {
+			d.entries[parent] = map[string]*FileInfo{}
+		}
+		d.entries[parent][filepath.Base(v.Path)] = v
+	}
+}
+
+// Crash simulates a power loss: all changes which are not durable are lost.
+// Crash requires TrackDurability.
+func (fs *FS) Crash() {
+	fs.record("Crash", "/", nil, nil)
+	fs.crash(nil)
+}
+
+// CrashRandom simulates a power loss where each change which is not durable
+// is lost or not, chosen randomly with seed. Pages of files may be torn,
+// i.e. only the beginning of a page was written. CrashRandom requires
+// TrackDurability.
+func (fs *FS) CrashRandom(seed int64) {
+	fs.record("Crash", "/", nil, testdouble.Args{"seed": seed})
+	fs.crash(rand.New(rand.NewSource(seed)))
+}
+
+func (fs *FS) crash(r *rand.Rand) {
+	if fs.durability == nil {
+		return
+	}
+	root := fs.PathStubs["/"]
+	c := &crash{fs: fs, rand: r, moved: map[*FileInfo]bool{}, tree: map[string]*FileInfo{"/": root}}
+	c.restore(root, "/")
+	fs.PathStubs = c.tree
+	fs.used = nil
+	fs.syncAll()
+}
+
+// crash rebuilds the tree of a FS after a crash.
+type crash struct {
+	fs   *FS
+	rand *rand.Rand
+	// moved holds for each file if its current location survives
+	moved map[*FileInfo]bool
+	tree  map[string]*FileInfo
+}
+
+// current returns true if the current location of fi survives the crash.
+// The decision is made once per file, so renames are atomic.
+func (c *crash) current(fi *FileInfo) bool {
+	if c.rand == nil {
+		return false
+	}
+	v, ok := c.moved[fi]
+	if !ok {
+		v = c.rand.Intn(2) == 0
+		c.moved[fi] = v
+	}
+	return v
+}
+
+// restore adds the entries of dir which survive the crash to the tree.
+func (c *crash) restore(dir *FileInfo, path string) {
+	durable := c.fs.durability.entries[dir]
+	current := map[string]*FileInfo{}
+	if c.fs.PathStubs[dir.Path] == dir {
+		current = c.fs.getDirEntries(dir.Path)
+	}
+	names := []string{}
+	for name := range durable {
+		names = append(names, name)
+	}
+	for name := range current {
+		if _, ok := durable[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		old, cur := durable[name], current[name]
+		var fi *FileInfo
+		switch {
+		case old == cur:
+			fi = old
+		case cur != nil && c.current(cur):
+			fi = cur
+		case old != nil && (cur != nil || !c.current(old)):
+			fi = old
+		}
+		if fi == nil || c.tree[fi.Path] == fi {
+			continue
+		}
+		p := filepath.Join(path, name)
+		fi.FName = name
+		fi.Path = p
+		c.tree[p] = fi
+		if fi.IsDir() {
+			c.restore(fi, p)
+			continue
+		}
+		fi.Data = c.fs.crashData(fi, c.rand)
+		fi.FSize = int64(len(fi.Data))
+		if bytes.Equal(fi.Data, c.fs.durability.data[fi]) {
+			fi.FSize = c.fs.durability.size[fi]
+		}
+	}
+}
+
+// crashData returns the data of a file after a crash.
+func (fs *FS) crashData(fi *FileInfo, r *rand.Rand) []byte {
+	old := fs.durability.data[fi]
+	if r == nil {
+		return old
+	}
+	size := len(old)
+	if r.Intn(2) == 0 {
+		size = len(fi.Data)
+	}
+	data := make([]byte, size)
+	pageSize := fs.durability.pageSize
+	for off := 0; off < size; off += pageSize {
+		end := off + pageSize
+		if end > size {
+			end = size
+		}
+		torn := off
+		switch r.Intn(3) {
+		case 1:
+			torn = end
+		case 2:
+			torn = off + r.Intn(end-off+1)
+		}
+		copy(data[off:torn], page(fi.Data, off, torn))
+		copy(data[torn:end], page(old, torn, end))
+	}
+	return data
+}
+
+// page returns data[from:to] or the part of it which exists.
+func page(data []byte, from int, to int) []byte {
+	if from >= len(data) {
+		return nil
+	}
+	if to > len(data) {
+		to = len(data)
+	}
+	return data[from:to]
+}
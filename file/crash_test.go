@@ -0,0 +1,173 @@
+package file
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// replace replaces /home/file1 with write-temp, fsync, rename, fsync-dir
+// and stops after the given number of steps.
+func replace(t *testing.T, fs *FS, steps int) {
+	f, err := fs.Create("/home/tmp")
+	assert.NoError(t, err)
+	_, err = f.WriteString("new")
+	assert.NoError(t, err)
+	if steps > 1 {
+		assert.NoError(t, f.Sync())
+	}
+	assert.NoError(t, f.Close())
+	if steps > 2 {
+		assert.NoError(t, fs.Rename("/home/tmp", "/home/file1"))
+	}
+	if steps > 3 {
+		dir, err := fs.Open("/home")
+		assert.NoError(t, err)
+		assert.NoError(t, dir.Sync())
+	}
+}
+
+func TestFS_Crash(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(t *testing.T, fs *FS)
+		wantData string
+		wantTmp  bool
+	}{
+		{
+			name:     "write",
+			op:       func(t *testing.T, fs *FS) { replace(t, fs, 1) },
+			wantData: "file1",
+		},
+		{
+			name:     "fsync",
+			op:       func(t *testing.T, fs *FS) { replace(t, fs, 2) },
+			wantData: "file1",
+		},
+		{
+			name:     "rename",
+			op:       func(t *testing.T, fs *FS) { replace(t, fs, 3) },
+			wantData: "file1",
+		},
+		{
+			name:     "fsyncDir",
+			op:       func(t *testing.T, fs *FS) { replace(t, fs, 4) },
+			wantData: "new",
+		},
+		{
+			name: "createSyncedDir",
+			op: func(t *testing.T, fs *FS) {
+				replace(t, fs, 2)
+				dir, _ := fs.Open("/home")
+				dir.Sync()
+			},
+			wantData: "file1",
+			wantTmp:  true,
+		},
+		{
+			name:     "overwrite",
+			op:       func(t *testing.T, fs *FS) { fs.WriteFile("/home/file1", []byte("new"), 0644) },
+			wantData: "file1",
+		},
+		{
+			name:     "remove",
+			op:       func(t *testing.T, fs *FS) { fs.Remove("/home/file1") },
+			wantData: "file1",
+		},
+		{
+			name: "sync",
+			op: func(t *testing.T, fs *FS) {
+				replace(t, fs, 3)
+				fs.Sync()
+			},
+			wantData: "new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs(), WithDurability(4))
+			tt.op(t, fs)
+			fs.Crash()
+			data, err := fs.ReadFile("/home/file1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantData, string(data))
+			_, err = fs.Stat("/home/tmp")
+			assert.Equal(t, tt.wantTmp, err == nil)
+		})
+	}
+}
+
+func TestFS_Crash_size(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(fs *FS)
+	}{
+		{
+			name: "unchanged",
+			op:   func(fs *FS) {},
+		},
+		{
+			name: "truncate",
+			op:   func(fs *FS) { fs.Truncate("/home/big", 0) },
+		},
+		{
+			name: "overwrite",
+			op:   func(fs *FS) { fs.WriteFile("/home/big", []byte("new"), 0644) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubs := homeStubs()
+			stubs["/home/big"] = &FileInfo{FSize: 100}
+			fs := newFS(t, stubs, WithDurability(4))
+			tt.op(fs)
+			fs.Crash()
+			fi, err := fs.Stat("/home/big")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(100), fi.Size())
+		})
+	}
+}
+
+func TestFS_CrashRandom(t *testing.T) {
+	run := func(seed int64) string {
+		fs := newFS(t, homeStubs(), WithDurability(4))
+		fs.WriteFile("/home/file1", []byte("aaaaaaaaaa"), 0644)
+		fs.Sync()
+		fs.WriteFile("/home/file1", []byte("bbbbbbbbbb"), 0644)
+		fs.CrashRandom(seed)
+		data, err := fs.ReadFile("/home/file1")
+		assert.NoError(t, err)
+		return string(data)
+	}
+	torn := false
+	for seed := int64(0); seed < 20; seed++ {
+		data := run(seed)
+		assert.Equal(t, data, run(seed))
+		assert.Len(t, data, 10)
+		for off := 0; off < len(data); off += 4 {
+			end := off + 4
+			if end > len(data) {
+				end = len(data)
+			}
+			p := data[off:end]
+			assert.Equal(t, p, strings.Repeat("b", strings.Count(p, "b"))+strings.Repeat("a", strings.Count(p, "a")))
+			torn = torn || strings.Contains(p, "ba")
+		}
+	}
+	assert.True(t, torn)
+}
+
+func TestFS_CrashRandomRename(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		fs := newFS(t, homeStubs(), WithDurability(4))
+		fs.Rename("/home/file1", "/home/file2")
+		fs.CrashRandom(seed)
+		_, err1 := fs.Stat("/home/file1")
+		_, err2 := fs.Stat("/home/file2")
+		assert.True(t, err1 == nil || err2 == nil)
+		assert.False(t, os.IsNotExist(err1) && os.IsNotExist(err2))
+	}
+}
@@ -0,0 +1,204 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Mkdir is a stub for os.Mkdir
+func (fs *FS) Mkdir(name string, perm os.FileMode) error {
+	req := &Request{Op: "Mkdir", Path: fs.resolve(name), Args: testdouble.Args{"perm": perm}}
+	resp := fs.run(req, func(oc *OpContext) {
+		if oc.Err = fs.checkName(name, false); oc.Err == nil {
+			oc.Err = fs.mkdir(oc.Path, perm, "Mkdir")
+		}
+	})
+	return pathError("mkdir", name, resp.Err)
+}
+
+func (fs *FS) mkdir(path string, perm os.FileMode, op string) error {
+	if _, ok := fs.PathStubs[path]; ok {
+		return syscall.EEXIST
+	}
+	parent, err := fs.requireParent(path, op)
+	if err != nil {
+		return err
+	}
+	if err := fs.allocInode(); err != nil {
+		return err
+	}
+	now := fs.now()
+	fs.charge(0, 1)
+	fs.PathStubs[path] = &FileInfo{
+		FName:    filepath.Base(path),
+		FMode:    os.ModeDir | perm&os.ModePerm,
+		FModTime: now,
+		FCTime:   now,
+		FATime:   now,
+		FIsDir:   true,
+		Path:     path,
+	}
+	fs.touch(parent)
+	return nil
+}
+
+// MkdirAll is a stub for os.MkdirAll
+func (fs *FS) MkdirAll(name string, perm os.FileMode) error {
+	req := &Request{Op: "MkdirAll", Path: fs.resolve(name), Args: testdouble.Args{"perm": perm}}
+	resp := fs.run(req, func(oc *OpContext) {
+		oc.Err = fs.mkdirAllName(name, perm)
+	})
+	return pathError("mkdir", name, resp.Err)
+}
+
+// mkdirAllName is mkdirAll for name as written. Like os.MkdirAll, it
+// creates the parents of each element, so /a/../b creates /a and /b.
+func (fs *FS) mkdirAllName(name string, perm os.FileMode) error {
+	path := fs.resolve(name)
+	if abs := fs.absName(name); filepath.Clean(abs) == abs {
+		return fs.mkdirAll(path, perm)
+	}
+	isDir := func() (bool, error) {
+		fi, err := fs.lookup(name, path, "MkdirAll")
+		return err == nil && fi.IsDir(), err
+	}
+	if ok, err := isDir(); err == nil {
+		if !ok {
+			return syscall.ENOTDIR
+		}
+		return nil
+	}
+	sep := string(os.PathSeparator)
+	if i := strings.LastIndex(strings.TrimRight(name, sep), sep); i > 0 {
+		if err := fs.mkdirAllName(name[:i], perm); err != nil {
+			return err
+		}
+	}
+	err := fs.checkName(name, false)
+	if err == nil {
+		err = fs.mkdir(path, perm, "MkdirAll")
+	}
+	if ok, _ := isDir(); err != nil && !ok {
+		return err
+	}
+	return nil
+}
+
+func (fs *FS) mkdirAll(path string, perm os.FileMode) error {
+	if v, ok := fs.PathStubs[path]; ok {
+		if v.Error != nil {
+			return v.Error
+		}
+		if !v.IsDir() {
+			return syscall.ENOTDIR
+		}
+		return nil
+	}
+	if err := fs.mkdirAll(filepath.Dir(path), perm); err != nil {
+		return err
+	}
+	return fs.mkdir(path, perm, "MkdirAll")
+}
+
+// Remove is a stub for os.Remove
+func (fs *FS) Remove(name string) error {
+	req := &Request{Op: "Remove", Path: fs.resolve(name)}
+	resp := fs.run(req, func(oc *OpContext) {
+		fi, err := fs.lookup(name, oc.Path, "Remove")
+		if err == nil {
+			err = removeDots(name)
+		}
+		if err == nil && fi.IsDir() && len(fs.getDirEntries(oc.Path)) > 0 {
+			err = syscall.ENOTEMPTY
+		}
+		if err == nil && oc.Path == string(os.PathSeparator) {
+			err = syscall.EBUSY
+		}
+		if err != nil {
+			oc.Err = err
+			return
+		}
+		fs.remove(oc.Path)
+	})
+	return pathError("remove", name, resp.Err)
+}
+
+// RemoveAll is a stub for os.RemoveAll
+func (fs *FS) RemoveAll(name string) error {
+	req := &Request{Op: "RemoveAll", Path: fs.resolve(name)}
+	resp := fs.run(req, func(oc *OpContext) {
+		if filepath.Base(name) == "." {
+			oc.Err = syscall.EINVAL
+			return
+		}
+		if oc.Err = fs.checkName(name, false); oc.Err != nil {
+			if os.IsNotExist(oc.Err) {
+				oc.Err = nil
+			}
+			return
+		}
+		if oc.Err = removeDots(name); oc.Err != nil {
+			// like os.RemoveAll, the content of the directory is removed
+			// before rmdir(2) fails
+			fs.removeChildren(oc.Path)
+			return
+		}
+		oc.Err = fs.removeAll(oc.Path)
+	})
+	return pathError("unlinkat", name, resp.Err)
+}
+
+// removeDots returns the error of rmdir(2) for a name ending with . or ..,
+// which cannot be removed.
+func removeDots(name string) error {
+	switch filepath.Base(name) {
+	case ".":
+		return syscall.EINVAL
+	case "..":
+		return syscall.ENOTEMPTY
+	}
+	return nil
+}
+
+func (fs *FS) removeAll(path string) error {
+	if path == "" {
+		return nil
+	}
+	if path == string(os.PathSeparator) {
+		return syscall.EBUSY
+	}
+	fi, ok := fs.PathStubs[path]
+	if ok && fi.Error != nil {
+		return fi.Error
+	}
+	if !ok {
+		if err := fs.notExist(path); err != os.ErrNotExist {
+			return err
+		}
+	}
+	fs.removeChildren(path)
+	if _, ok := fs.PathStubs[path]; ok {
+		fs.remove(path)
+	}
+	return nil
+}
+
+// removeChildren removes the files below path.
+func (fs *FS) removeChildren(path string) {
+	for k := range fs.PathStubs {
+		if k != path && isWithin(k, path) {
+			fs.deleteStub(k)
+		}
+	}
+}
+
+func (fs *FS) remove(path string) {
+	fs.deleteStub(path)
+	if parent, ok := fs.PathStubs[filepath.Dir(path)]; ok {
+		fs.touch(parent)
+	}
+}
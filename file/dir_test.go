@@ -0,0 +1,90 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Mkdir(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		all     bool
+		wantErr error
+	}{
+		{name: "mkdir", dir: "/home/new"},
+		{name: "mkdirAll", dir: "/home/a/b/c", all: true},
+		{name: "mkdirAllExisting", dir: "/home/dir", all: true},
+		{name: "errorExist", dir: "/home/dir", wantErr: os.ErrExist},
+		{name: "errorNoParent", dir: "/home/a/b", wantErr: os.ErrNotExist},
+		{name: "errorParentIsFile", dir: "/home/file1/a", wantErr: syscall.ENOTDIR},
+		{name: "errorAllParentIsFile", dir: "/home/file1/a/b", all: true, wantErr: syscall.ENOTDIR},
+		{name: "mkdirAllDotDot", dir: "/home/a/../b", all: true},
+		{name: "errorDotDotNoParent", dir: "/home/a/../b", wantErr: os.ErrNotExist},
+		{name: "errorAllTrailingFile", dir: "/home/file1/", all: true, wantErr: os.ErrExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			var err error
+			if tt.all {
+				err = fs.MkdirAll(tt.dir, 0755)
+			} else {
+				err = fs.Mkdir(tt.dir, 0755)
+			}
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			fi, err := fs.Stat(tt.dir)
+			assert.NoError(t, err)
+			assert.True(t, fi.IsDir())
+		})
+	}
+}
+
+func TestFS_Remove(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		all      bool
+		wantGone []string
+		wantErr  error
+	}{
+		{name: "file", path: "/home/file1", wantGone: []string{"/home/file1"}},
+		{name: "emptyDir", path: "/home/empty", wantGone: []string{"/home/empty"}},
+		{name: "removeAll", path: "/home/dir", all: true, wantGone: []string{"/home/dir", "/home/dir/file2"}},
+		{name: "removeAllNotExist", path: "/home/invalid", all: true},
+		{name: "errorNotEmpty", path: "/home/dir", wantErr: syscall.ENOTEMPTY},
+		{name: "errorNotExist", path: "/home/invalid", wantErr: os.ErrNotExist},
+		{name: "errorRoot", path: "/", all: true, wantErr: syscall.EBUSY},
+		{name: "removeAllDotDotNotExist", path: "/home/invalid/../dir", all: true},
+		{name: "errorDotDot", path: "/home/empty/..", wantErr: syscall.ENOTEMPTY},
+		{name: "errorDot", path: "/home/empty/.", all: true, wantErr: syscall.EINVAL},
+		{name: "errorTrailingFile", path: "/home/file1/", wantErr: syscall.ENOTDIR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			var err error
+			if tt.all {
+				err = fs.RemoveAll(tt.path)
+			} else {
+				err = fs.Remove(tt.path)
+			}
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, p := range tt.wantGone {
+				assert.Nil(t, fs.FileInfo(p), p)
+			}
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package file
 
 import (
+	"context"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -24,6 +26,13 @@ type FileInfo struct {
 	FMode os.FileMode
 	// FModTime is the file modification time
 	FModTime time.Time
+	// FModTimeOffset is added to the time of the FS clock when the file is
+	// added to a FS (used for relative times like mtime=-2h)
+	FModTimeOffset time.Duration
+	// FCTime is the time of the last status change
+	FCTime time.Time
+	// FATime is the time of the last access
+	FATime time.Time
 	// FIsDir is true for a directory
 	FIsDir bool
 
@@ -33,21 +42,58 @@ type FileInfo struct {
 	Data []byte
 	// Path is the full path of the file
 	Path string
+	// Sequence holds responses for successive reads (see Configer.Then)
+	Sequence *Sequence
+	// ReadChunk and WriteChunk limit the bytes per Read and Write of a
+	// File (0 means no limit).
+	ReadChunk  int
+	WriteChunk int
+	// FailAfter lets a File fail with FailError after reading or writing
+	// FailAfter bytes (0 disables it).
+	FailAfter int64
+	// FailError is returned after FailAfter bytes (default:
+	// io.ErrUnexpectedEOF for reads and EIO for writes).
+	FailError error
+	// Delay delays every operation on the file (using the clock of the FS)
+	Delay time.Duration
+	// Transient lets the first attempts of operations fail (see
+	// Configer.FailFirst)
+	Transient *Transient
 }
 
 type Configer interface {
 	Data(...[]byte) []byte
 	Error(...error) error
 	Mode(...os.FileMode) os.FileMode
+	// Then, ThenError and OnExhausted configure a sequence of responses
+	// for successive reads (ex: Then(data1).ThenError(EIO).Then(data2)).
+	Then(data []byte) Configer
+	ThenError(err error) Configer
+	OnExhausted(v Exhausted) Configer
+	// ReadChunk, WriteChunk and FailAfter configure short reads, short
+	// writes and mid-stream errors of File.
+	ReadChunk(n int) Configer
+	WriteChunk(n int) Configer
+	FailAfter(n int64, err error) Configer
+	// Delay delays every operation on the file.
+	Delay(d time.Duration) Configer
+	// FailFirst lets the first n attempts of each of ops (all operations
+	// if empty) fail with err. Attempts returns the attempts of op and
+	// ResetAttempts resets them.
+	FailFirst(n int, err error, ops ...string) Configer
+	Attempts(op string) int
+	ResetAttempts() Configer
 }
 
 type setter struct {
+	fs *FS
 	fi *FileInfo
 }
 
 func (s *setter) Data(v ...[]byte) []byte {
 	if len(v) == 1 {
 		s.fi.Data = v[0]
+		s.fs.used = nil
 	}
 	return s.fi.Data
 }
@@ -56,7 +102,7 @@ func (s *setter) Mode(v ...os.FileMode) os.FileMode {
 	if len(v) == 1 {
 		s.fi.FMode = v[0]
 	}
-	return s.fi.Mode()
+	return s.fi.FMode
 }
 
 func (s *setter) Error(v ...error) error {
@@ -66,6 +112,46 @@ func (s *setter) Error(v ...error) error {
 	return s.fi.Error
 }
 
+func (s *setter) ReadChunk(n int) Configer {
+	s.fi.ReadChunk = n
+	return s
+}
+
+func (s *setter) WriteChunk(n int) Configer {
+	s.fi.WriteChunk = n
+	return s
+}
+
+func (s *setter) FailAfter(n int64, err error) Configer {
+	s.fi.FailAfter = n
+	s.fi.FailError = err
+	return s
+}
+
+func (s *setter) Delay(d time.Duration) Configer {
+	s.fi.Delay = d
+	return s
+}
+
+func (s *setter) FailFirst(n int, err error, ops ...string) Configer {
+	s.fi.Transient = &Transient{Err: err, N: n, Ops: ops}
+	return s
+}
+
+func (s *setter) Attempts(op string) int {
+	if s.fi.Transient == nil {
+		return 0
+	}
+	return s.fi.Transient.Attempts(op)
+}
+
+func (s *setter) ResetAttempts() Configer {
+	if s.fi.Transient != nil {
+		s.fi.Transient.Reset()
+	}
+	return s
+}
+
 type Option func(*FS)
 
 func WithFiles(files []*FileInfo) Option {
@@ -76,10 +162,48 @@ func WithFiles(files []*FileInfo) Option {
 
 type FS struct {
 	*testdouble.TestDouble
-	PathStubs     map[string]*FileInfo
+	PathStubs map[string]*FileInfo
+	// AbsPathPrefix is used as working directory as long as Chdir has not
+	// been called.
 	AbsPathPrefix string
 	AbsPathError  error
 	t             *testing.T
+	// cwd is the current working directory (see Chdir)
+	cwd string
+	// clock is used for timestamps (see WithClock)
+	clock Clock
+	// relTimes holds the files whose FModTime was derived from
+	// FModTimeOffset and not changed since (see SetClock)
+	relTimes map[*FileInfo]bool
+	// tempDir is the directory returned by TempDir (see WithTempDir)
+	tempDir string
+	// tempRand generates temp file names (see WithTempSeed)
+	tempRand *rand.Rand
+	// doublestar enables ** in Glob patterns (see WithDoublestar)
+	doublestar bool
+	// dirOrder is the order of raw directory entries (see WithDirOrder)
+	dirOrder DirOrder
+	// strict and strictAllow are used by the strict mode (see WithStrict)
+	strict      testing.TB
+	strictAllow []string
+	// removed holds the removed paths, strict mode does not report them
+	removed map[string]bool
+	// coverage tracks accessed paths (see TrackCoverage)
+	coverage *coverage
+	// rules inject faults (see AddRule)
+	rules []*Rule
+	// durability tracks durable changes (see TrackDurability)
+	durability *durability
+	// quota limits the capacity (see SetQuota)
+	quota *Quota
+	// used is the running total of used bytes and inodes, nil if it has to
+	// be computed (see usage)
+	used *usageTotal
+	// before and after hold hooks (see OnBefore)
+	before []hook
+	after  []hook
+	// interceptors is the chain of interceptors (see Use)
+	interceptors []Interceptor
 }
 
 func CreateFS(td *testdouble.TestDouble, opts ...Option) *FS {
@@ -88,16 +212,16 @@ func CreateFS(td *testdouble.TestDouble, opts ...Option) *FS {
 		PathStubs:     make(map[string]*FileInfo),
 		AbsPathPrefix: "",
 	}
+	fs.PathStubs["/"] = &FileInfo{FName: "/", Path: "/", FIsDir: true}
 
 	for _, opt := range opts {
 		opt(fs)
 	}
-	fs.PathStubs["/"] = &FileInfo{FName: "/", Path: "/", FIsDir: true}
 	return fs
 }
 
 func (fs *FS) FileInfo(p string) os.FileInfo {
-	if v, ok := fs.PathStubs[p]; ok {
+	if v, ok := fs.PathStubs[fs.resolve(p)]; ok {
 		return v
 	}
 	return nil
@@ -106,160 +230,261 @@ func (fs *FS) FileInfo(p string) os.FileInfo {
 // Config provides access to stubs
 func (fs *FS) Config(p string) Configer {
 	var fi *FileInfo
-	if v, ok := fs.PathStubs[p]; ok {
+	if v, ok := fs.PathStubs[fs.resolve(p)]; ok {
 		fi = v
 	}
 	if fi == nil {
 		return nil
 	}
-	s := &setter{fi: fi}
+	s := &setter{fs: fs, fi: fi}
 	return s
 }
 
 func (fs *FS) AddFiles(in []*FileInfo) {
 	for _, v := range in {
 		if v.Path != "" && v.Path != "/" {
+			if v.FModTimeOffset != 0 && v.FModTime.IsZero() {
+				v.FModTime = fs.now().Add(v.FModTimeOffset)
+				if fs.relTimes == nil {
+					fs.relTimes = map[*FileInfo]bool{}
+				}
+				fs.relTimes[v] = true
+			}
 			fs.PathStubs[v.Path] = v
+			fs.used = nil
+			fs.declare(v.Path)
 		}
 	}
+	fs.syncFixtures(in)
+}
+
+// record records a call in the spy of the test double.
+func (fs *FS) record(op string, path string, err error, args testdouble.Args) {
+	if fs.TestDouble == nil {
+		return
+	}
+	fs.TestDouble.Record(testdouble.Call{Op: op, Path: path, Args: args, Err: err, Time: fs.now()})
+}
+
+// lookup is getFile for path resolved from name (see checkName).
+func (fs *FS) lookup(name string, path string, op string) (*FileInfo, error) {
+	if err := fs.checkName(name, true); err != nil {
+		return nil, err
+	}
+	return fs.getFile(path, op)
 }
 
 func (fs *FS) getFile(path string, op string) (*FileInfo, error) {
 	if v, ok := fs.PathStubs[path]; ok {
 		if v.Error != nil {
-			fs.TestDouble.Log("return pre-configured error").Path(path).Operation("getFile").Error(v.Error).Done()
 			return nil, v.Error
 
 		}
-		// fs.TestDouble.Log("return os.FileInfo").Path(path).Operation("getFile").Done()
+		fs.access(path)
 		return v, nil
 	}
-	fs.TestDouble.Log("return os.ErrNotExist").Path(path).Operation("getFile").Error(os.ErrNotExist).Done()
-	return nil, os.ErrNotExist
+	fs.missing(path, op)
+	return nil, fs.notExist(path)
 }
 
-func (fs *FS) getDirEntries(dirname string) map[string]*FileInfo {
+// lookupError wraps err of a failed lookup of path in a PathError unless it
+// was configured for the file (see Configer.Error).
+func (fs *FS) lookupError(op string, name string, path string, err error) error {
+	if v, ok := fs.PathStubs[path]; ok && v.Error == err {
+		return err
+	}
+	return pathError(op, name, err)
+}
 
-	tmpFiles := make(map[string]*FileInfo)
-	for k, v := range fs.PathStubs {
-		if strings.HasPrefix(k, dirname) || dirname == "/" {
-			f := strings.TrimPrefix(k, dirname)
-			g := strings.Split(f, string(os.PathSeparator))
-			fname := ""
-			if dirname == "/" && len(g) > 0 {
-
-				fname = g[0]
-			} else {
-				if len(g) == 2 {
-					fname = g[1]
-				}
-			}
-			if fname != "" {
-				if _, ok := tmpFiles[fname]; !ok {
-					tmpFiles[fname] = v
-				}
+// notExist returns the error for the missing path: ENOTDIR if the nearest
+// existing parent of path is a file, os.ErrNotExist otherwise.
+func (fs *FS) notExist(path string) error {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if v, ok := fs.PathStubs[dir]; ok {
+			if !v.IsDir() {
+				return syscall.ENOTDIR
 			}
+			return os.ErrNotExist
+		}
+		if dir == filepath.Dir(dir) {
+			return os.ErrNotExist
 		}
 	}
-	return tmpFiles
 }
 
-func (fs *FS) ReadDir(dirname string) ([]os.FileInfo, error) {
+func (fs *FS) getDirEntries(dirname string) map[string]*FileInfo {
 
-	if err := fs.requireDir(dirname, "ReadDir"); err != nil {
-		return nil, err
+	tmpFiles := make(map[string]*FileInfo)
+	for k, v := range fs.PathStubs {
+		if k != dirname && filepath.Dir(k) == dirname {
+			tmpFiles[filepath.Base(k)] = v
+		}
 	}
+	return tmpFiles
+}
 
-	tmpFiles := fs.getDirEntries(dirname)
-	retval := make([]os.FileInfo, 0)
-	for _, v := range tmpFiles {
-		if v.Error != nil {
-			fs.TestDouble.Log("return pre-configured error").Path(dirname).Operation("ReadDir").Error(v.Error).Done()
-			return nil, v.Error
+func (fs *FS) ReadDir(name string) ([]os.FileInfo, error) {
+	req := &Request{Op: "ReadDir", Path: fs.resolve(name), Args: testdouble.Args{"count": 0}}
+	resp := fs.run(req, func(oc *OpContext) {
+		dirname := oc.Path
+		err := fs.checkName(name, true)
+		if err == nil {
+			err = fs.requireDir(dirname, "ReadDir")
+		}
+		if err != nil {
+			oc.Err = fs.lookupError("open", name, dirname, err)
+			return
+		}
+		fs.touchAccess(fs.PathStubs[dirname])
+		tmpFiles := fs.getDirEntries(dirname)
+		retval := make([]os.FileInfo, 0)
+		for name, v := range tmpFiles {
+			if v.Error != nil {
+				oc.Err = v.Error
+				return
+			}
+			retval = append(retval, v)
+			fs.access(filepath.Join(dirname, name))
 		}
-		retval = append(retval, v)
+		sortByName(retval)
+		oc.Result = retval
+		oc.Args["count"] = len(retval)
+	})
+	entries, _ := resp.Result.([]os.FileInfo)
+	return entries, resp.Err
+}
 
-	}
-	fs.TestDouble.Log("return return []os.FileInfo").Path(dirname).Operation("ReadDir").Done()
-	return retval, nil
+func (fs *FS) Stat(name string) (os.FileInfo, error) {
+	return fs.stat(context.Background(), name)
 }
 
-func (fs *FS) Stat(path string) (os.FileInfo, error) {
+// StatContext is like Stat, but returns ctx.Err() if ctx is done before the
+// delays of the file elapsed.
+func (fs *FS) StatContext(ctx context.Context, name string) (os.FileInfo, error) {
+	return fs.stat(ctx, name)
+}
 
-	fi, err := fs.getFile(path, "Stat")
-	if err != nil {
-		return nil, err
-	}
-	return fi, nil
+func (fs *FS) stat(ctx context.Context, name string) (os.FileInfo, error) {
+	req := &Request{Op: "Stat", Path: fs.resolve(name), Context: ctx}
+	resp := fs.run(req, func(oc *OpContext) {
+		fi, err := fs.lookup(name, oc.Path, "Stat")
+		if err != nil {
+			oc.Err = fs.lookupError("stat", name, oc.Path, err)
+			return
+		}
+		oc.Result = fi
+	})
+	fi, _ := resp.Result.(os.FileInfo)
+	return fi, resp.Err
 }
 
-func (fs *FS) ReadFile(path string) ([]byte, error) {
+func (fs *FS) ReadFile(name string) ([]byte, error) {
+	return fs.readFile(context.Background(), name)
+}
 
-	fi, err := fs.getFile(path, "ReadFile")
-	if err != nil {
-		return nil, err
-	}
-	return fi.Data, nil
+// ReadFileContext is like ReadFile, but returns ctx.Err() if ctx is done
+// before the delays of the file elapsed.
+func (fs *FS) ReadFileContext(ctx context.Context, name string) ([]byte, error) {
+	return fs.readFile(ctx, name)
+}
+
+func (fs *FS) readFile(ctx context.Context, name string) ([]byte, error) {
+	req := &Request{Op: "ReadFile", Path: fs.resolve(name), Context: ctx}
+	resp := fs.run(req, func(oc *OpContext) {
+		fi, err := fs.lookup(name, oc.Path, "ReadFile")
+		if err == nil && fi.IsDir() {
+			err = syscall.EISDIR
+		}
+		if err != nil {
+			oc.Err = fs.lookupError("open", name, oc.Path, err)
+			return
+		}
+		fs.touchAccess(fi)
+		if fi.Sequence != nil {
+			if fi, err = fs.nextStep(fi); err != nil {
+				oc.Err = err
+				return
+			}
+		}
+		oc.Args = testdouble.Args{"size": len(fi.Data)}
+		oc.Result = applyTransform(oc.Transform, fi.Data)
+	})
+	data, _ := resp.Result.([]byte)
+	return data, resp.Err
 }
 
 // Walk is a stub for filepath.Walk
 func (fs *FS) requireDir(path string, op string) error {
 	if v, ok := fs.PathStubs[path]; ok {
 		if v.Error != nil {
-			fs.TestDouble.Log("return pre-configured error").Path(path).Operation(op).Error(v.Error).Done()
 			return v.Error
 		}
 		if !v.IsDir() {
-			fs.TestDouble.Log("return pre-configured error").Path(path).Operation(op).Error(os.ErrInvalid).Done()
-			return os.ErrInvalid
+			return syscall.ENOTDIR
 		}
+		fs.access(path)
 	} else {
-		fs.TestDouble.Log("return pre-configured error").Path(path).Operation(op).Error(os.ErrNotExist).Done()
-		return os.ErrNotExist
+		fs.missing(path, op)
+		return fs.notExist(path)
 	}
 	return nil
 }
 
 // Walk is a stub for filepath.Walk
 func (fs *FS) Walk(root string, walkFn filepath.WalkFunc) error {
-
-	Once.Do(func() {
-
+	req := &Request{Op: "Walk", Path: fs.resolve(root)}
+	resp := fs.run(req, func(oc *OpContext) {
+		fi, err := fs.lookup(root, oc.Path, "Walk")
+		if err != nil {
+			oc.Err = fs.lookupError("lstat", root, oc.Path, err)
+			return
+		}
+		oc.Result = fi
 	})
+	var err error
+	if fi, ok := resp.Result.(*FileInfo); ok && resp.Err == nil {
+		err = fs.walk(root, req.Path, fi, walkFn)
+	} else {
+		err = walkFn(root, nil, resp.Err)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
 
-	if err := fs.requireDir(root, "Walk"); err != nil {
+// walk calls walkFn for path and the files below it in lexical order like
+// filepath.Walk. name is the path passed to walkFn.
+func (fs *FS) walk(name string, path string, fi *FileInfo, walkFn filepath.WalkFunc) error {
+	if !fi.IsDir() {
+		return walkFn(name, fi, nil)
+	}
+	if err := walkFn(name, fi, nil); err != nil {
 		return err
 	}
-
-	keys := []string{}
-	for k, _ := range fs.PathStubs {
-		keys = append(keys, k)
+	entries := fs.getDirEntries(path)
+	names := make([]string, 0, len(entries))
+	for k := range entries {
+		names = append(names, k)
 	}
-	// log.Println(">>>>>> Walk keys before sort", keys)
-	sort.Strings(keys)
-	// log.Println(">>>>>> Walk keys after sort", keys)
-	for _, k := range keys {
-		if strings.HasPrefix(k, root) {
-			fi, err := fs.getFile(k, "walk")
-			fs.TestDouble.Log("calling walkFn").Path(k).Operation("Walk").Done()
-			walkFn(k, fi, err)
+	sort.Strings(names)
+	for _, k := range names {
+		child, err := fs.getFile(filepath.Join(path, k), "Walk")
+		if err != nil {
+			if err := walkFn(filepath.Join(name, k), nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := fs.walk(filepath.Join(name, k), filepath.Join(path, k), child, walkFn); err != nil {
+			if !child.IsDir() || err != filepath.SkipDir {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-func (fs *FS) WriteFile(filename string, data []byte, perm os.FileMode) error {
-
-	return nil
-}
-
-func (fs *FS) Abs(p string) (string, error) {
-	if fs.AbsPathError != nil {
-		return "", fs.AbsPathError
-	}
-	return filepath.Join(fs.AbsPathPrefix, p), nil
-}
-
 // NewFile creates a new file. It is used to simplify the interface when only
 // names are used.
 func NewFile(name string, args ...interface{}) os.FileInfo {
@@ -303,11 +528,22 @@ func (fi *FileInfo) Name() string { return fi.FName }
 func (fi *FileInfo) Size() int64 { return fi.FSize }
 
 // Mode returns the FileMode of the file
-func (fi *FileInfo) Mode() os.FileMode { return fi.FMode }
+func (fi *FileInfo) Mode() os.FileMode {
+	if fi.FIsDir {
+		return fi.FMode | os.ModeDir
+	}
+	return fi.FMode
+}
 
 // ModTime returns the modification time of the file
 func (fi *FileInfo) ModTime() time.Time { return fi.FModTime }
 
+// ChangeTime returns the time of the last status change of the file
+func (fi *FileInfo) ChangeTime() time.Time { return fi.FCTime }
+
+// AccessTime returns the time of the last access of the file
+func (fi *FileInfo) AccessTime() time.Time { return fi.FATime }
+
 // Sys is not used but here to satisfy the interface
 func (fi *FileInfo) Sys() interface{} { return nil }
 
@@ -5,12 +5,54 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"syscall"
 	"testing"
 
 	"github.com/shebang-go/fsmocker/testdouble"
 	"github.com/stretchr/testify/assert"
 )
 
+// newFS creates an FS logging to t which holds stubs keyed by path like
+// PathStubs. Paths and names of the stubs are set from the keys, opts are
+// applied before the stubs are added.
+func newFS(t *testing.T, stubs map[string]*FileInfo, opts ...Option) *FS {
+	paths := make([]string, 0, len(stubs))
+	for p := range stubs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	files := make([]*FileInfo, 0, len(paths))
+	for _, p := range paths {
+		fi := stubs[p]
+		fi.Path = p
+		if fi.FName == "" {
+			fi.FName = filepath.Base(p)
+		}
+		files = append(files, fi)
+	}
+	td := testdouble.NewTestDouble(testdouble.WithLogging(t)).(*testdouble.TestDouble)
+	return CreateFS(td, append(opts, WithFiles(files))...)
+}
+
+var errBad = errors.New("bad")
+
+// homeStubs returns the files most tests of the package run on.
+func homeStubs() map[string]*FileInfo {
+	return map[string]*FileInfo{
+		"/home":                   {FIsDir: true},
+		"/home/file1":             {Data: []byte("file1"), FSize: 5, FMode: 0644},
+		"/home/dir":               {FIsDir: true},
+		"/home/dir/file2":         {Data: []byte("file2")},
+		"/home/empty":             {FIsDir: true},
+		"/home/bad":               {Error: errBad},
+		"/home/maggy":             {FIsDir: true},
+		"/home/maggy/config.yaml": {Data: []byte("cfg")},
+		"/home/maggy/b":           {FIsDir: true},
+		"/home/maggy/b/file1":     {Data: []byte("file1")},
+	}
+}
+
 func TestFS_ReadDir(t *testing.T) {
 	type fields struct {
 		PathStubs map[string]*FileInfo
@@ -246,6 +288,29 @@ func TestFS_ReadFile(t *testing.T) {
 	}
 }
 
+func TestFS_lookupError(t *testing.T) {
+	configured := errors.New("configured")
+	tests := []struct {
+		name    string
+		op      func(fs *FS) error
+		wantErr error
+	}{
+		{name: "statMissing", op: func(fs *FS) error { _, err := fs.Stat("/home/x"); return err }, wantErr: &os.PathError{Op: "stat", Path: "/home/x", Err: os.ErrNotExist}},
+		{name: "statBelowFile", op: func(fs *FS) error { _, err := fs.Stat("/home/maggy/config.yaml/x"); return err }, wantErr: &os.PathError{Op: "stat", Path: "/home/maggy/config.yaml/x", Err: syscall.ENOTDIR}},
+		{name: "readFileDir", op: func(fs *FS) error { _, err := fs.ReadFile("/home/maggy"); return err }, wantErr: &os.PathError{Op: "open", Path: "/home/maggy", Err: syscall.EISDIR}},
+		{name: "readDirFile", op: func(fs *FS) error { _, err := fs.ReadDir("maggy/config.yaml"); return err }, wantErr: &os.PathError{Op: "open", Path: "maggy/config.yaml", Err: syscall.ENOTDIR}},
+		{name: "configured", op: func(fs *FS) error { _, err := fs.Stat("/home/maggy/b"); return err }, wantErr: configured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.Config("/home/maggy/b").Error(configured)
+			assert.NoError(t, fs.Chdir("/home"))
+			assert.Equal(t, tt.wantErr, tt.op(fs))
+		})
+	}
+}
+
 func TestFS_Walk(t *testing.T) {
 	type fields struct {
 		TestDouble    testdouble.TestDouble
@@ -293,11 +358,10 @@ func TestFS_Walk(t *testing.T) {
 			name:    "errorInvalidPath",
 			fields:  fields{PathStubs: map[string]*FileInfo{}},
 			args:    args{root: "/invalid"},
-			wantErr: errors.New("file does not exist"),
+			wantErr: errors.New("lstat /invalid: file does not exist"),
 		},
 		{
-			name: "errorNotADirectory",
-
+			name: "file(/home/maggy/file1)",
 			fields: fields{PathStubs: map[string]*FileInfo{
 				"/home/maggy":              {FName: "maggy", FIsDir: true},
 				"/home/maggy/file1":        {FName: "file1"},
@@ -305,8 +369,8 @@ func TestFS_Walk(t *testing.T) {
 				"/home/maggy/subdir":       {FName: "subdir", FIsDir: true},
 				"/home/maggy/subdir/file3": {FName: "file3"},
 			}},
-			args:    args{root: "/home/maggy/file1"},
-			wantErr: errors.New("invalid argument"),
+			args: args{root: "/home/maggy/file1"},
+			want: []string{"/home/maggy/file1"},
 		},
 	}
 	for _, tt := range tests {
@@ -330,6 +394,33 @@ func TestFS_Walk(t *testing.T) {
 	}
 }
 
+func TestFS_WalkFnResult(t *testing.T) {
+	stop := errors.New("stop")
+	tests := []struct {
+		name    string
+		result  map[string]error
+		want    []string
+		wantErr error
+	}{
+		{name: "skipDir", result: map[string]error{"/home/maggy/b": filepath.SkipDir}, want: []string{"/home/maggy", "/home/maggy/b", "/home/maggy/config.yaml"}},
+		{name: "skipDirOfFile", result: map[string]error{"/home/maggy/b/file1": filepath.SkipDir}, want: []string{"/home/maggy", "/home/maggy/b", "/home/maggy/b/file1", "/home/maggy/config.yaml"}},
+		{name: "skipRoot", result: map[string]error{"/home/maggy": filepath.SkipDir}, want: []string{"/home/maggy"}},
+		{name: "error", result: map[string]error{"/home/maggy/b": stop}, want: []string{"/home/maggy", "/home/maggy/b"}, wantErr: stop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			got := []string{}
+			err := fs.Walk("/home/maggy", func(path string, f os.FileInfo, err error) error {
+				got = append(got, path)
+				return tt.result[path]
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestFS_Config(t *testing.T) {
 	type args struct {
 		p string
@@ -399,3 +490,9 @@ func TestFS_Config(t *testing.T) {
 		})
 	}
 }
+
+func TestFileInfo_Mode(t *testing.T) {
+	assert.Equal(t, os.FileMode(0644), (&FileInfo{FMode: 0644}).Mode())
+	assert.Equal(t, os.ModeDir|0755, (&FileInfo{FMode: 0755, FIsDir: true}).Mode())
+	assert.True(t, (&FileInfo{FIsDir: true}).Mode().IsDir())
+}
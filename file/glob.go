@@ -0,0 +1,263 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// WithDoublestar is an option to enable ** in Glob patterns. ** matches zero
+// or more directories.
+func WithDoublestar() Option {
+	return func(fs *FS) {
+		fs.SetDoublestar(true)
+	}
+}
+
+// SetDoublestar enables or disables ** in Glob patterns.
+func (fs *FS) SetDoublestar(v bool) {
+	fs.doublestar = v
+}
+
+// Glob is a stub for filepath.Glob. I/O errors are ignored like in
+// filepath.Glob, so directories with a pre-configured error are skipped.
+func (fs *FS) Glob(pattern string) ([]string, error) {
+	req := &Request{Op: "Glob", Path: pattern, Args: testdouble.Args{"count": 0}}
+	resp := fs.run(req, func(oc *OpContext) {
+		if _, oc.Err = filepath.Match(pattern, ""); oc.Err != nil {
+			return
+		}
+		var matches []string
+		if fs.doublestar {
+			matches, oc.Err = fs.globDoublestar(pattern)
+		} else {
+			matches, oc.Err = fs.glob(pattern, 0)
+		}
+		for _, m := range matches {
+			fs.access(fs.resolve(m))
+		}
+		if oc.Err == nil {
+			oc.Result = matches
+			oc.Args["count"] = len(matches)
+		}
+	})
+	matches, _ := resp.Result.([]string)
+	return matches, resp.Err
+}
+
+// glob is filepath.Glob using the stub instead of the os package.
+func (fs *FS) glob(pattern string, depth int) (matches []string, err error) {
+	// Check pattern is well-formed.
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if depth > 10000 {
+		return nil, filepath.ErrBadPattern
+	}
+	if !hasMeta(pattern) {
+		if _, err = fs.getFile(fs.resolve(pattern), "Glob"); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := filepath.Split(pattern)
+	dir = cleanGlobPath(dir)
+
+	if !hasMeta(dir) {
+		return fs.globDir(dir, file, nil)
+	}
+
+	// Prevent infinite recursion.
+	if dir == pattern {
+		return nil, filepath.ErrBadPattern
+	}
+
+	var m []string
+	m, err = fs.glob(dir, depth+1)
+	if err != nil {
+		return
+	}
+	for _, d := range m {
+		matches, err = fs.globDir(d, file, matches)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// globDir appends the names in dir matching pattern to matches.
+func (fs *FS) globDir(dir, pattern string, matches []string) (m []string, e error) {
+	m = matches
+	names, ok := fs.dirNames(dir)
+	if !ok {
+		return // ignore I/O error
+	}
+	for _, n := range names {
+		matched, err := filepath.Match(pattern, n)
+		if err != nil {
+			return m, err
+		}
+		if matched {
+			m = append(m, filepath.Join(dir, n))
+		}
+	}
+	return
+}
+
+// dirNames returns the sorted names of the entries in dir. ok is false if dir
+// can not be read.
+func (fs *FS) dirNames(dir string) (names []string, ok bool) {
+	path := fs.resolve(dir)
+	fi, err := fs.getFile(path, "Glob")
+	if err != nil || !fi.IsDir() {
+		return nil, false
+	}
+	for name := range fs.getDirEntries(path) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+func (fs *FS) globDoublestar(pattern string) ([]string, error) {
+	dir := ""
+	if filepath.IsAbs(pattern) {
+		dir = string(os.PathSeparator)
+	}
+	segs := []string{}
+	for _, s := range strings.Split(pattern, string(os.PathSeparator)) {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	found := make(map[string]bool)
+	if err := fs.globSegments(dir, segs, found); err != nil {
+		return nil, err
+	}
+	matches := []string{}
+	for k := range found {
+		matches = append(matches, k)
+	}
+	sort.Strings(matches)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return matches, nil
+}
+
+func (fs *FS) globSegments(dir string, segs []string, found map[string]bool) error {
+	if len(segs) == 0 {
+		if dir != "" {
+			found[dir] = true
+		}
+		return nil
+	}
+	lookup := dir
+	if lookup == "" {
+		lookup = "."
+	}
+	seg := segs[0]
+	switch {
+	case seg == "**":
+		if err := fs.globSegments(dir, segs[1:], found); err != nil {
+			return err
+		}
+		names, _ := fs.dirNames(lookup)
+		for _, n := range names {
+			child := filepath.Join(dir, n)
+			if fi, err := fs.getFile(fs.resolve(child), "Glob"); err == nil && fi.IsDir() {
+				if err := fs.globSegments(child, segs, found); err != nil {
+					return err
+				}
+			} else if len(segs) == 1 {
+				found[child] = true
+			}
+		}
+	case !hasMeta(seg):
+		child := filepath.Join(dir, seg)
+		if _, err := fs.getFile(fs.resolve(child), "Glob"); err == nil {
+			return fs.globSegments(child, segs[1:], found)
+		}
+	default:
+		names, _ := fs.dirNames(lookup)
+		for _, n := range names {
+			matched, err := filepath.Match(seg, n)
+			if err != nil {
+				return err
+			}
+			if matched {
+				if err := fs.globSegments(filepath.Join(dir, n), segs[1:], found); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// cleanGlobPath prepares path for glob matching.
+func cleanGlobPath(path string) string {
+	switch path {
+	case "":
+		return "."
+	case string(os.PathSeparator):
+		// do nothing to the path
+		return path
+	default:
+		return path[0 : len(path)-1] // chop off trailing separator
+	}
+}
+
+// hasMeta reports whether path contains any of the magic characters
+// recognized by filepath.Match.
+func hasMeta(path string) bool {
+	magicChars := `*?[`
+	if runtime.GOOS != "windows" {
+		magicChars = `*?[\`
+	}
+	return strings.ContainsAny(path, magicChars)
+}
+
+// MatchPattern reports whether path matches pattern. Pattern elements have
+// the syntax of filepath.Match, the element ** matches zero or more path
+// elements.
+func MatchPattern(pattern string, path string) bool {
+	return matchSegments(splitPath(pattern), splitPath(path))
+}
+
+func splitPath(p string) []string {
+	retval := []string{}
+	for _, s := range strings.Split(p, string(os.PathSeparator)) {
+		if s != "" {
+			retval = append(retval, s)
+		}
+	}
+	return retval
+}
+
+func matchSegments(pattern []string, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, err := filepath.Match(pattern[0], path[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
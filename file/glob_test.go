@@ -0,0 +1,132 @@
+package file
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var globFiles = []string{
+	"a.go", "b.go", "c.txt", ".hidden",
+	"sub/a.go", "sub/d.txt", "sub/deep/e.go",
+	"sub2/f.go", "x[1]/g.go",
+}
+
+// globFS creates the same tree in a temp dir and a stub.
+func globFS(t *testing.T) (string, *FS) {
+	root := t.TempDir()
+
+	fs := newFS(t, nil)
+	assert.NoError(t, fs.MkdirAll(root, 0755))
+	for _, f := range globFiles {
+		p := filepath.Join(root, f)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, ioutil.WriteFile(p, nil, 0644))
+		assert.NoError(t, fs.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, fs.WriteFile(p, nil, 0644))
+	}
+	return root, fs
+}
+
+func TestFS_Glob(t *testing.T) {
+	patterns := []string{
+		"*", "*.go", "?.go", "[ab].go", "[^a].go", "*/*.go", "*/*/*", "sub*/*",
+		"sub", "invalid", "invalid/*", "x\\[1\\]/*", "*/a.go", ".*", "sub/deep/e.go",
+		"[a-", "*/[",
+	}
+	root, fs := globFS(t)
+	for _, p := range patterns {
+		t.Run(p, func(t *testing.T) {
+			want, wantErr := filepath.Glob(filepath.Join(root, p))
+			got, err := fs.Glob(filepath.Join(root, p))
+			assert.Equal(t, wantErr, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestFS_Glob_relative(t *testing.T) {
+	root, fs := globFS(t)
+	assert.NoError(t, fs.Chdir(root))
+	got, err := fs.Glob("sub/*.go")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sub/a.go"}, got)
+}
+
+func TestFS_Glob_readError(t *testing.T) {
+	root, fs := globFS(t)
+	fs.Config(filepath.Join(root, "sub")).Error(errors.New("bad"))
+	got, err := fs.Glob(filepath.Join(root, "*/*.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "sub2/f.go"), filepath.Join(root, "x[1]/g.go")}, got)
+}
+
+func TestFS_Glob_doublestar(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "**/*.go", want: []string{"a.go", "b.go", "sub/a.go", "sub/deep/e.go", "sub2/f.go", "x[1]/g.go"}},
+		{pattern: "sub/**", want: []string{"sub", "sub/a.go", "sub/d.txt", "sub/deep", "sub/deep/e.go"}},
+		{pattern: "sub/**/e.go", want: []string{"sub/deep/e.go"}},
+		{pattern: "**/deep/*", want: []string{"sub/deep/e.go"}},
+		{pattern: "**/**/d.txt", want: []string{"sub/d.txt"}},
+		{pattern: "**/*.none"},
+	}
+	root, fs := globFS(t)
+	fs.SetDoublestar(true)
+	assert.NoError(t, fs.Chdir(root))
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := fs.Glob(tt.pattern)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			abs := []string{}
+			for _, p := range tt.want {
+				abs = append(abs, filepath.Join(root, p))
+			}
+			got, err = fs.Glob(filepath.Join(root, tt.pattern))
+			assert.NoError(t, err)
+			if len(abs) == 0 {
+				abs = nil
+			}
+			assert.Equal(t, abs, got)
+		})
+	}
+
+	_, err := fs.Glob("**/[")
+	assert.Equal(t, filepath.ErrBadPattern, err)
+
+	fs.Config(filepath.Join(root, "sub")).Error(errors.New("bad"))
+	got, err := fs.Glob("**/*.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c.txt"}, got)
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/home/*", path: "/home/a", want: true},
+		{pattern: "/home/*", path: "/home/a/b", want: false},
+		{pattern: "/home/**", path: "/home/a/b", want: true},
+		{pattern: "/home/**", path: "/home", want: true},
+		{pattern: "/**/*.go", path: "/src/pkg/a.go", want: true},
+		{pattern: "/**/*.go", path: "/a.go", want: true},
+		{pattern: "/src/**/test/*.go", path: "/src/test/a.go", want: true},
+		{pattern: "/src/**/test/*.go", path: "/src/a/test/b/a.go", want: false},
+		{pattern: "/[", path: "/[", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+":"+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchPattern(tt.pattern, tt.path))
+		})
+	}
+}
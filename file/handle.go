@@ -0,0 +1,363 @@
+package file
+
+import (
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Handle is an open file. It is implemented by *File, *os.File and the
+// files returned by NewOsFile.
+type Handle interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Name() string
+	Stat() (os.FileInfo, error)
+	Readdir(n int) ([]os.FileInfo, error)
+	Readdirnames(n int) ([]string, error)
+	ReadDir(n int) ([]iofs.DirEntry, error)
+	Sync() error
+}
+
+// File is a stub for os.File. It is returned by the Open* and *Temp methods
+// of a FS.
+type File struct {
+	fs     *FS
+	fi     *FileInfo
+	name   string
+	path   string
+	flag   int
+	offset int64
+	closed bool
+	// transferred counts the bytes read and written (see FileInfo.FailAfter)
+	transferred int64
+	// dirEntries and dirOffset are used by Readdir
+	dirEntries []os.FileInfo
+	dirOffset  int
+	// corrupted is the content read after a rule corrupted it (see Read)
+	corrupted []byte
+}
+
+// OpenFile is a stub for os.OpenFile
+func (fs *FS) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	return fs.open("OpenFile", name, flag, perm)
+}
+
+// open opens a file and records the call as op.
+func (fs *FS) open(op string, name string, flag int, perm os.FileMode) (*File, error) {
+	req := &Request{Op: op, Path: fs.resolve(name), Args: testdouble.Args{"flag": flag, "perm": perm}}
+	resp := fs.run(req, func(oc *OpContext) {
+		if flag&os.O_CREATE != 0 {
+			oc.Err = fs.checkCreate(name, oc.Path, op)
+		} else {
+			oc.Err = fs.checkName(name, true)
+		}
+		if oc.Err != nil {
+			return
+		}
+		fi, err := fs.openFile(oc.Path, flag, perm)
+		if err == nil && fi.Sequence != nil && !isWritable(flag) && !fi.IsDir() {
+			fi, err = fs.nextStep(fi)
+		}
+		if err != nil {
+			oc.Err = err
+			return
+		}
+		if flag&os.O_TRUNC != 0 && isWritable(flag) {
+			old := sizeOf(fi)
+			fi.Data = nil
+			fi.FSize = 0
+			fs.resized(fi, old)
+			fs.touch(fi)
+		}
+		oc.Result = &File{fs: fs, fi: fi, name: name, path: oc.Path, flag: flag}
+	})
+	f, _ := resp.Result.(*File)
+	return f, pathError("open", name, resp.Err)
+}
+
+// isDirName returns true if name can only be a directory: / or names
+// ending with a separator, . or ..
+func isDirName(name string) bool {
+	base := filepath.Base(name)
+	return strings.HasSuffix(name, string(os.PathSeparator)) || base == "." || base == ".."
+}
+
+// checkCreate returns the error of open(2) with O_CREAT for name before the
+// file is looked up (see checkName).
+func (fs *FS) checkCreate(name string, path string, op string) error {
+	if err := fs.checkName(name, false); err != nil {
+		return err
+	}
+	if !isDirName(name) {
+		return nil
+	}
+	// O_CREAT fails for a name which can only be a directory
+	if _, err := fs.requireParent(path, op); err != nil {
+		return err
+	}
+	return syscall.EISDIR
+}
+
+// nextStep returns a snapshot of fi with the data of the next step of its
+// sequence.
+func (fs *FS) nextStep(fi *FileInfo) (*FileInfo, error) {
+	step := fi.Sequence.Next()
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	snapshot := *fi
+	snapshot.Data = step.Data
+	snapshot.FSize = int64(len(step.Data))
+	return &snapshot, nil
+}
+
+func (fs *FS) openFile(path string, flag int, perm os.FileMode) (*FileInfo, error) {
+	if flag&os.O_CREATE == 0 {
+		fi, err := fs.getFile(path, "OpenFile")
+		if err != nil {
+			return nil, err
+		}
+		if fi.IsDir() && isWritable(flag) {
+			return nil, syscall.EISDIR
+		}
+		return fi, nil
+	}
+	if _, ok := fs.PathStubs[path]; ok && flag&os.O_EXCL != 0 {
+		return nil, syscall.EEXIST
+	}
+	return fs.createFile(path, perm, "OpenFile")
+}
+
+// Open is a stub for os.Open
+func (fs *FS) Open(name string) (*File, error) {
+	return fs.open("Open", name, os.O_RDONLY, 0)
+}
+
+// Create is a stub for os.Create
+func (fs *FS) Create(name string) (*File, error) {
+	return fs.open("Create", name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func isWritable(flag int) bool {
+	return flag&(os.O_WRONLY|os.O_RDWR) != 0
+}
+
+func isReadable(flag int) bool {
+	return flag&os.O_WRONLY == 0
+}
+
+// Name returns the name of the file as presented to Open.
+func (f *File) Name() string { return f.name }
+
+// Stat returns the FileInfo of the file.
+func (f *File) Stat() (os.FileInfo, error) {
+	if f.closed {
+		return nil, &os.PathError{Op: "stat", Path: f.name, Err: os.ErrClosed}
+	}
+	return f.fi, nil
+}
+
+// run runs a File method as operation File.op (see FS.run). Errors are
+// wrapped like errors of package os.
+func (f *File) run(op string, pathOp string, args testdouble.Args, fn func(oc *OpContext)) *Response {
+	resp := f.fs.run(&Request{Op: "File." + op, Path: f.path, Args: args}, fn)
+	resp.Err = fileError(pathOp, f.name, resp.Err)
+	return resp
+}
+
+// Read reads up to len(b) bytes from the file.
+func (f *File) Read(b []byte) (int, error) {
+	resp := f.run("Read", "read", testdouble.Args{"len": len(b), "n": 0}, func(oc *OpContext) {
+		if oc.Err = f.check("read", isReadable(f.flag)); oc.Err != nil {
+			return
+		}
+		if f.fi.IsDir() {
+			oc.Err = syscall.EISDIR
+			return
+		}
+		if oc.Transform != nil && f.corrupted == nil {
+			f.corrupted = applyTransform(oc.Transform, f.fi.Data)
+		}
+		data := f.fi.Data
+		if f.corrupted != nil {
+			data = f.corrupted
+		}
+		if f.offset >= int64(len(data)) {
+			oc.Err = io.EOF
+			return
+		}
+		limit, fail := f.limit(len(b), f.fi.ReadChunk)
+		if l, ok := oc.Args["len"].(int); ok && l >= 0 && l < limit {
+			// shortened by an interceptor
+			limit = l
+		}
+		n := copy(b[:limit], data[f.offset:])
+		f.offset += int64(n)
+		f.transferred += int64(n)
+		f.fs.touchAccess(f.fi)
+		oc.Result = n
+		oc.Args["n"] = n
+		if fail && f.transferred >= f.fi.FailAfter {
+			oc.Err = f.failError(io.ErrUnexpectedEOF)
+		}
+	})
+	n, _ := resp.Result.(int)
+	return n, resp.Err
+}
+
+// Write writes len(b) bytes to the file.
+func (f *File) Write(b []byte) (int, error) {
+	resp := f.run("Write", "write", testdouble.Args{"len": len(b), "n": 0}, func(oc *OpContext) {
+		if oc.Err = f.check("write", isWritable(f.flag)); oc.Err != nil {
+			return
+		}
+		n, err := f.write(b, oc.Transform)
+		oc.Result = n
+		oc.Args["n"] = n
+		oc.Err = err
+	})
+	n, _ := resp.Result.(int)
+	return n, resp.Err
+}
+
+func (f *File) write(b []byte, transform func([]byte) []byte) (int, error) {
+	data := applyTransform(transform, b)
+	limit, fail := f.limit(len(data), f.fi.WriteChunk)
+	data = data[:limit]
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.fi.Data))
+	}
+	end := f.offset + int64(len(data))
+	size := int64(len(f.fi.Data))
+	if len(data) > 0 && end > size {
+		size = end
+	}
+	allowed, quotaErr := f.fs.allocBytes(f.fi, size)
+	if quotaErr != nil {
+		end = allowed
+		if end < f.offset {
+			end = f.offset
+		}
+		data = data[:end-f.offset]
+	}
+	old := sizeOf(f.fi)
+	f.corrupted = nil
+	// empty writes past EOF do not grow the file
+	if len(data) > 0 {
+		if end > int64(len(f.fi.Data)) {
+			grown := make([]byte, end)
+			copy(grown, f.fi.Data)
+			f.fi.Data = grown
+		}
+		f.offset += int64(copy(f.fi.Data[f.offset:], data))
+	}
+	f.transferred += int64(len(data))
+	f.fi.FSize = int64(len(f.fi.Data))
+	f.fs.resized(f.fi, old)
+	f.fs.touch(f.fi)
+	if limit > len(b) {
+		limit = len(b)
+	}
+	if quotaErr != nil {
+		n := len(data)
+		if n > len(b) {
+			n = len(b)
+		}
+		return n, quotaErr
+	}
+	if fail {
+		return limit, f.failError(syscall.EIO)
+	}
+	if limit < len(b) {
+		return limit, io.ErrShortWrite
+	}
+	return len(b), nil
+}
+
+// limit returns how many of n bytes a Read or Write may transfer with the
+// given chunk size and whether the transfer reaches FailAfter.
+func (f *File) limit(n int, chunk int) (int, bool) {
+	if chunk > 0 && n > chunk {
+		n = chunk
+	}
+	if f.fi.FailAfter > 0 {
+		if left := f.fi.FailAfter - f.transferred; int64(n) >= left {
+			if left < 0 {
+				left = 0
+			}
+			return int(left), true
+		}
+	}
+	return n, false
+}
+
+// failError returns the error configured by FileInfo.FailError or def.
+func (f *File) failError(def error) error {
+	if f.fi.FailError != nil {
+		return f.fi.FailError
+	}
+	return def
+}
+
+// WriteString is like Write, but writes the contents of string s.
+func (f *File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Seek sets the offset for the next Read or Write.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if err := f.check("seek", true); err != nil {
+		return 0, err
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.fi.Data))
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Sync commits the contents of the file, or the entries of a directory, to
+// durable storage (see TrackDurability).
+func (f *File) Sync() error {
+	resp := f.run("Sync", "sync", nil, func(oc *OpContext) {
+		if oc.Err = f.check("sync", true); oc.Err == nil {
+			f.fs.sync(f.fi)
+		}
+	})
+	return resp.Err
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	resp := f.run("Close", "close", nil, func(oc *OpContext) {
+		if oc.Err = f.check("close", true); oc.Err == nil {
+			f.closed = true
+		}
+	})
+	return resp.Err
+}
+
+func (f *File) check(op string, allowed bool) error {
+	if f == nil {
+		return os.ErrInvalid
+	}
+	if f.closed {
+		return &os.PathError{Op: op, Path: f.name, Err: os.ErrClosed}
+	}
+	if !allowed {
+		return &os.PathError{Op: op, Path: f.name, Err: syscall.EBADF}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package file
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ Handle = &File{}
+	_ Handle = &os.File{}
+	_ Handle = &osFile{}
+)
+
+func TestFS_OpenFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		flag     int
+		wantData []byte
+		wantErr  error
+	}{
+		{name: "readOnly", filename: "/home/file1", flag: os.O_RDONLY, wantData: []byte("file1")},
+		{name: "truncate", filename: "/home/file1", flag: os.O_RDWR | os.O_TRUNC, wantData: []byte{}},
+		{name: "create", filename: "/home/new", flag: os.O_RDWR | os.O_CREATE, wantData: []byte{}},
+		{name: "createExisting", filename: "/home/file1", flag: os.O_RDWR | os.O_CREATE, wantData: []byte("file1")},
+		{name: "errorExclusive", filename: "/home/file1", flag: os.O_RDWR | os.O_CREATE | os.O_EXCL, wantErr: os.ErrExist},
+		{name: "errorNotExist", filename: "/home/new", flag: os.O_RDONLY, wantErr: os.ErrNotExist},
+		{name: "errorWriteDir", filename: "/home/dir", flag: os.O_WRONLY, wantErr: syscall.EISDIR},
+		{name: "errorPreConfigured", filename: "/home/bad", flag: os.O_RDONLY, wantErr: errBad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			f, err := fs.OpenFile(tt.filename, tt.flag, 0644)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.filename, f.Name())
+			data, err := ioutil.ReadAll(f)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantData, data)
+			assert.NoError(t, f.Close())
+		})
+	}
+}
+
+func TestFile_ReadWrite(t *testing.T) {
+	fs := newFS(t, homeStubs())
+
+	f, err := fs.Create("/home/new")
+	assert.NoError(t, err)
+	n, err := f.WriteString("hello world")
+	assert.NoError(t, err)
+	assert.Equal(t, 11, n)
+
+	_, err = f.Seek(6, io.SeekStart)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("there"))
+	assert.NoError(t, err)
+
+	_, err = f.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+	buf := make([]byte, 5)
+	n, err = f.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(buf[:n]))
+
+	fi, err := f.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), fi.Size())
+	assert.NoError(t, f.Sync())
+	assert.NoError(t, f.Close())
+
+	_, err = f.Read(buf)
+	assert.True(t, errors.Is(err, os.ErrClosed))
+	assert.True(t, errors.Is(f.Close(), os.ErrClosed))
+
+	data, _ := fs.ReadFile("/home/new")
+	assert.Equal(t, []byte("hello there"), data)
+}
+
+func TestFile_emptyWritePastEOF(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	f, err := fs.Create("/home/new")
+	assert.NoError(t, err)
+	_, err = f.Seek(100, io.SeekStart)
+	assert.NoError(t, err)
+	n, err := f.Write(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	fi, err := f.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), fi.Size())
+}
+
+func TestFile_modes(t *testing.T) {
+	fs := newFS(t, homeStubs())
+
+	f, _ := fs.Open("/home/file1")
+	_, err := f.Write([]byte("x"))
+	assert.True(t, errors.Is(err, syscall.EBADF))
+
+	f, _ = fs.OpenFile("/home/file1", os.O_WRONLY|os.O_APPEND, 0)
+	_, err = f.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, syscall.EBADF))
+	_, err = f.WriteString("+")
+	assert.NoError(t, err)
+	data, _ := fs.ReadFile("/home/file1")
+	assert.Equal(t, []byte("file1+"), data)
+
+	f, _ = fs.Open("/home/dir")
+	_, err = f.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, syscall.EISDIR))
+}
+
+func TestFile_streamFaults(t *testing.T) {
+	type result struct {
+		n   int
+		err error
+	}
+	tests := []struct {
+		name   string
+		config func(c Configer)
+		write  bool
+		want   []result
+	}{
+		{
+			name:   "readChunk",
+			config: func(c Configer) { c.ReadChunk(2) },
+			want:   []result{{n: 2}, {n: 2}, {n: 1}, {err: io.EOF}},
+		},
+		{
+			name:   "failAfter",
+			config: func(c Configer) { c.ReadChunk(2).FailAfter(3, nil) },
+			want:   []result{{n: 2}, {n: 1, err: io.ErrUnexpectedEOF}, {err: io.ErrUnexpectedEOF}},
+		},
+		{
+			name:   "failAfterErrno",
+			config: func(c Configer) { c.FailAfter(4, syscall.EIO) },
+			want:   []result{{n: 4, err: syscall.EIO}},
+		},
+		{
+			name:   "writeChunk",
+			config: func(c Configer) { c.WriteChunk(2) },
+			write:  true,
+			want:   []result{{n: 2, err: io.ErrShortWrite}, {n: 2, err: io.ErrShortWrite}},
+		},
+		{
+			name:   "writeFailAfter",
+			config: func(c Configer) { c.FailAfter(3, nil) },
+			write:  true,
+			want:   []result{{n: 3, err: syscall.EIO}, {err: syscall.EIO}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			tt.config(fs.Config("/home/file1"))
+			f, err := fs.OpenFile("/home/file1", os.O_RDWR, 0)
+			assert.NoError(t, err)
+			got := []result{}
+			for range tt.want {
+				var n int
+				if tt.write {
+					n, err = f.Write([]byte("12345"))
+				} else {
+					n, err = f.Read(make([]byte, 8))
+				}
+				if pe, ok := err.(*os.PathError); ok {
+					err = pe.Err
+				}
+				got = append(got, result{n: n, err: err})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFile_readFull(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.Config("/home/file1").ReadChunk(1)
+	f, _ := fs.Open("/home/file1")
+	data, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "file1", string(data))
+	assert.Equal(t, 6, fs.CallCount("File.Read", "/home/file1"))
+}
@@ -0,0 +1,95 @@
+package file
+
+import (
+	"io"
+	"os"
+)
+
+// OpContext describes an operation of a FS for hooks (see OnBefore and
+// OnAfter). Hooks may change the FS, OnAfter hooks may replace Result with a
+// value of the same type and Err.
+type OpContext struct {
+	*Request
+	*Response
+}
+
+// HookFunc is called before or after an operation. A non-nil error fails
+// the operation.
+type HookFunc func(oc *OpContext) error
+
+type hook struct {
+	op      string
+	pattern string
+	fn      HookFunc
+}
+
+func (h hook) matches(oc *OpContext) bool {
+	if h.op != "" && h.op != "*" && h.op != oc.Op {
+		return false
+	}
+	return h.pattern == "" || MatchPattern(h.pattern, oc.Path)
+}
+
+// OnBefore registers fn to be called before each operation op (all
+// operations if empty or *) on paths matching pattern (all paths if empty,
+// see MatchPattern). If fn returns an error the operation is not run and
+// fails with the error.
+func (fs *FS) OnBefore(op string, pattern string, fn HookFunc) {
+	fs.before = append(fs.before, hook{op: op, pattern: pattern, fn: fn})
+}
+
+// OnAfter registers fn to be called after each operation op (all
+// operations if empty or *) on paths matching pattern (all paths if empty,
+// see MatchPattern). fn may change the result of the operation, an error
+// returned by fn replaces the error of the operation.
+func (fs *FS) OnAfter(op string, pattern string, fn HookFunc) {
+	fs.after = append(fs.after, hook{op: op, pattern: pattern, fn: fn})
+}
+
+func runHooks(hooks []hook, oc *OpContext) error {
+	for _, h := range hooks {
+		if !h.matches(oc) {
+			continue
+		}
+		if err := h.fn(oc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hooks calls the hooks of the FS (see OnBefore and OnAfter).
+func Hooks() Interceptor {
+	return func(req *Request, next Handler) *Response {
+		oc := &OpContext{Request: req, Response: &Response{}}
+		if err := runHooks(req.FS.before, oc); err != nil {
+			return &Response{Err: err}
+		}
+		oc.Response = next(req)
+		if err := runHooks(req.FS.after, oc); err != nil {
+			oc.Err = err
+		}
+		return oc.Response
+	}
+}
+
+// pathError wraps err in a *os.PathError unless it is wrapped already.
+func pathError(op string, name string, err error) error {
+	switch err.(type) {
+	case nil:
+		return nil
+	case *os.PathError, *os.LinkError:
+		return err
+	}
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+// fileError is pathError for File methods, which return errors of package
+// io as is.
+func fileError(op string, name string, err error) error {
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF, io.ErrShortWrite:
+		return err
+	}
+	return pathError(op, name, err)
+}
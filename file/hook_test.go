@@ -0,0 +1,104 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_OnBefore(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.OnBefore("ReadFile", "/home/file1", func(oc *OpContext) error {
+		if oc.FS.FileInfo("/home/b") != nil {
+			return syscall.EIO
+		}
+		return nil
+	})
+
+	_, err := fs.ReadFile("/home/file1")
+	assert.NoError(t, err)
+	assert.NoError(t, fs.WriteFile("/home/b", nil, 0644))
+	_, err = fs.ReadFile("/home/file1")
+	assert.Equal(t, syscall.EIO, err)
+	_, err = fs.ReadFile("/home/dir/file2")
+	assert.NoError(t, err)
+	assert.Equal(t, syscall.EIO, fs.CallsTo("ReadFile")[1].Err)
+}
+
+func TestFS_OnAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		op       string
+		pattern  string
+		hook     HookFunc
+		wantData string
+		wantErr  error
+	}{
+		{
+			name:     "result",
+			path:     "/home/file1",
+			op:       "ReadFile",
+			pattern:  "/home/*",
+			hook:     func(oc *OpContext) error { oc.Result = []byte("hooked"); return nil },
+			wantData: "hooked",
+		},
+		{
+			name:    "error",
+			path:    "/home/bad",
+			op:      "*",
+			hook:    func(oc *OpContext) error { return syscall.ESTALE },
+			wantErr: syscall.ESTALE,
+		},
+		{
+			name:    "recover",
+			path:    "/home/bad",
+			op:      "ReadFile",
+			pattern: "/home/**",
+			hook: func(oc *OpContext) error {
+				if oc.Err != nil {
+					oc.Err = nil
+					oc.Result = []byte("recovered")
+				}
+				return nil
+			},
+			wantData: "recovered",
+		},
+		{
+			name:    "otherOp",
+			path:    "/home/bad",
+			op:      "Stat",
+			hook:    func(oc *OpContext) error { return syscall.ESTALE },
+			wantErr: errBad,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.OnAfter(tt.op, tt.pattern, tt.hook)
+			data, err := fs.ReadFile(tt.path)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, string(data))
+		})
+	}
+}
+
+func TestFS_hookMutatesTree(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.OnAfter("Remove", "/home/file1", func(oc *OpContext) error {
+		return oc.FS.WriteFile("/home/file1.deleted", nil, 0644)
+	})
+	fs.OnBefore("Mkdir", "", func(oc *OpContext) error {
+		assert.Equal(t, os.FileMode(0700), oc.Args["perm"])
+		return errors.New("denied")
+	})
+
+	assert.NoError(t, fs.Remove("/home/file1"))
+	_, err := fs.Stat("/home/file1.deleted")
+	assert.NoError(t, err)
+	err = fs.Mkdir("/home/new", 0700)
+	assert.EqualError(t, err, "mkdir /home/new: denied")
+}
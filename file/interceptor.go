@@ -0,0 +1,170 @@
+package file
+
+import (
+	"context"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Request is an operation of a FS passed through its interceptors.
+type Request struct {
+	// FS is the file system of the operation.
+	FS *FS
+	// Op is the operation (ex: ReadFile, File.Read)
+	Op string
+	// Path is the resolved path of the operation (the pattern for Glob).
+	// Interceptors may rewrite it.
+	Path string
+	// Args holds the arguments of the operation as recorded by the spy.
+	// Interceptors may lower len of File.Read to shorten the read.
+	Args testdouble.Args
+	// Context is the context of the operation (see StatContext)
+	Context context.Context
+	// Transform transforms data read or written by the operation (see
+	// Rule.Transform)
+	Transform func([]byte) []byte
+}
+
+// Response is the result of an operation.
+type Response struct {
+	// Result holds the result of the operation besides the error (ex: the
+	// data of ReadFile).
+	Result interface{}
+	// Err is the error of the operation.
+	Err error
+}
+
+// Handler runs a request.
+type Handler func(req *Request) *Response
+
+// Interceptor intercepts a request. It calls next to continue with the
+// chain, or returns a response itself.
+type Interceptor func(req *Request, next Handler) *Response
+
+// DefaultInterceptors returns the interceptors of a FS in the order they
+// are called: Spy, Logging, Hooks and Faults.
+func DefaultInterceptors() []Interceptor {
+	return []Interceptor{Spy(), Logging(), Hooks(), Faults()}
+}
+
+// WithInterceptors is an option to add interceptors (see Use).
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(fs *FS) {
+		fs.Use(interceptors...)
+	}
+}
+
+// Use adds interceptors to the end of the chain, i.e. they are called after
+// the default interceptors right before the operation.
+func (fs *FS) Use(interceptors ...Interceptor) {
+	fs.interceptors = append(fs.Interceptors(), interceptors...)
+}
+
+// SetInterceptors replaces the chain of interceptors. Use
+// DefaultInterceptors to keep the built-in ones.
+func (fs *FS) SetInterceptors(interceptors ...Interceptor) {
+	fs.interceptors = append([]Interceptor{}, interceptors...)
+}
+
+// Interceptors returns the chain of interceptors.
+func (fs *FS) Interceptors() []Interceptor {
+	if fs.interceptors == nil {
+		return DefaultInterceptors()
+	}
+	return append([]Interceptor{}, fs.interceptors...)
+}
+
+// Spy records the request in the spy of the FS.
+func Spy() Interceptor {
+	return func(req *Request, next Handler) *Response {
+		start := req.FS.now()
+		resp := next(req)
+		if req.FS.TestDouble != nil {
+			req.FS.TestDouble.Record(testdouble.Call{Op: req.Op, Path: req.Path, Args: req.Args, Err: resp.Err,
+				Time: start, Duration: req.FS.now().Sub(start)})
+		}
+		return resp
+	}
+}
+
+// Logging logs the request with the logger of the FS.
+func Logging() Interceptor {
+	return func(req *Request, next Handler) *Response {
+		resp := next(req)
+		if req.FS.TestDouble == nil {
+			return resp
+		}
+		if resp.Err != nil {
+			req.FS.TestDouble.Log("return error").Level(testdouble.LevelError).Path(req.Path).Operation(req.Op).Error(resp.Err).Done()
+		} else {
+			req.FS.TestDouble.Log("return result").Path(req.Path).Operation(req.Op).Done()
+		}
+		return resp
+	}
+}
+
+// Faults applies the fault injection rules of the FS (see AddRule) and the
+// delays and transient errors of files.
+func Faults() Interceptor {
+	return func(req *Request, next Handler) *Response {
+		transform, err := req.FS.injectContext(req.Context, req.Op, req.Path)
+		if err != nil {
+			return &Response{Err: err}
+		}
+		if transform != nil {
+			req.Transform = chainTransforms(req.Transform, transform)
+		}
+		return next(req)
+	}
+}
+
+func chainTransforms(first, second func([]byte) []byte) func([]byte) []byte {
+	if first == nil {
+		return second
+	}
+	return func(data []byte) []byte { return second(first(data)) }
+}
+
+// run runs the request through the interceptors and fn. fn sets
+// oc.Result on success and oc.Err on failure.
+func (fs *FS) run(req *Request, fn func(oc *OpContext)) *Response {
+	req.FS = fs
+	if req.Context == nil {
+		req.Context = context.Background()
+	}
+	h := func(req *Request) *Response {
+		oc := &OpContext{Request: req, Response: &Response{}}
+		fn(oc)
+		return oc.Response
+	}
+	interceptors := fs.interceptors
+	if interceptors == nil {
+		interceptors = DefaultInterceptors()
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		h = intercept(interceptors[i], h)
+	}
+	return h(req)
+}
+
+// Run runs req through the interceptors of the FS with h as the operation.
+// It is used for operations answered outside of the FS (ex: mocked calls of
+// a stub), so hooks, logging, faults and the spy see them like any other.
+// The path of req counts as accessed (see TrackCoverage), or as missing in
+// strict mode if it does not exist.
+func (fs *FS) Run(req *Request, h Handler) *Response {
+	return fs.run(req, func(oc *OpContext) {
+		if _, ok := fs.PathStubs[oc.Path]; ok {
+			fs.access(oc.Path)
+		} else {
+			fs.missing(oc.Path, oc.Op)
+		}
+		*oc.Response = *h(oc.Request)
+	})
+}
+
+func intercept(i Interceptor, next Handler) Handler {
+	return func(req *Request) *Response {
+		return i(req, next)
+	}
+}
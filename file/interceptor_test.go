@@ -0,0 +1,118 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+	"github.com/stretchr/testify/assert"
+)
+
+// rewrite is an interceptor which maps paths below /alias to /home.
+func rewrite(req *Request, next Handler) *Response {
+	if strings.HasPrefix(req.Path, "/alias/") {
+		req.Path = "/home/" + strings.TrimPrefix(req.Path, "/alias/")
+	}
+	return next(req)
+}
+
+func TestFS_Use(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(fs *FS) error
+		wantPath string
+		wantData string
+	}{
+		{
+			name:     "readFile",
+			op:       func(fs *FS) error { _, err := fs.ReadFile("/alias/file1"); return err },
+			wantPath: "/home/file1",
+			wantData: "file1",
+		},
+		{
+			name:     "writeFile",
+			op:       func(fs *FS) error { return fs.WriteFile("/alias/file1", []byte("new"), 0644) },
+			wantPath: "/home/file1",
+			wantData: "new",
+		},
+		{
+			name:     "rename",
+			op:       func(fs *FS) error { return fs.Rename("/alias/dir/file2", "/home/file1") },
+			wantPath: "/home/dir/file2",
+			wantData: "file2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.Use(rewrite)
+			assert.NoError(t, tt.op(fs))
+			assert.Equal(t, tt.wantPath, fs.Calls()[0].Path)
+			data, err := fs.ReadFile("/home/file1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantData, string(data))
+		})
+	}
+}
+
+func TestFS_Interceptors(t *testing.T) {
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(req *Request, next Handler) *Response {
+			order = append(order, name+" "+req.Op)
+			return next(req)
+		}
+	}
+	fs := newFS(t, homeStubs())
+	fs.Use(trace("first"), trace("second"))
+	assert.Len(t, fs.Interceptors(), len(DefaultInterceptors())+2)
+
+	_, err := fs.Stat("/home/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first Stat", "second Stat"}, order)
+}
+
+func TestFS_SetInterceptors(t *testing.T) {
+	audit := []string{}
+	deny := func(req *Request, next Handler) *Response {
+		audit = append(audit, req.Op+" "+req.Path)
+		if req.Op != "Stat" && req.Op != "ReadFile" {
+			return &Response{Err: syscall.EROFS}
+		}
+		return next(req)
+	}
+	fs := newFS(t, homeStubs())
+	fs.SetInterceptors(deny)
+
+	_, err := fs.ReadFile("/home/file1")
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(fs.WriteFile("/home/file1", nil, 0644), syscall.EROFS))
+	assert.True(t, errors.Is(fs.Remove("/home/file1"), syscall.EROFS))
+	assert.Equal(t, []string{"ReadFile /home/file1", "WriteFile /home/file1", "Remove /home/file1"}, audit)
+	assert.Empty(t, fs.Calls())
+
+	fs.SetInterceptors(append(DefaultInterceptors(), deny)...)
+	_, err = fs.Stat("/home/file1")
+	assert.NoError(t, err)
+	assert.Len(t, fs.Calls(), 1)
+}
+
+func TestLogging(t *testing.T) {
+	mem := testdouble.NewMemorySink()
+	fs := CreateFS(testdouble.NewTestDouble(testdouble.WithSink(mem)).(*testdouble.TestDouble), WithFiles([]*FileInfo{
+		{FName: "a", Path: "/a", Data: []byte("a")},
+	}))
+	_, _ = fs.ReadFile("/a")
+	_, _ = fs.Stat("/b")
+
+	entries := mem.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, testdouble.LevelInfo, entries[0].Level)
+	assert.Equal(t, "ReadFile", entries[0].Op)
+	assert.Equal(t, testdouble.LevelError, entries[1].Level)
+	assert.Equal(t, "/b", entries[1].Path)
+	assert.True(t, errors.Is(entries[1].Err, os.ErrNotExist))
+}
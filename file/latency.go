@@ -0,0 +1,36 @@
+package file
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Latency returns a delay using the random source of a rule (see
+// Rule.Latency).
+type Latency func(r *rand.Rand) time.Duration
+
+// Fixed returns the latency d.
+func Fixed(d time.Duration) Latency {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// Uniform returns a latency uniformly distributed in [min, max).
+func Uniform(min, max time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+// Normal returns a normally distributed latency. Negative values are 0.
+func Normal(mean, stddev time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		d := mean + time.Duration(r.NormFloat64()*float64(stddev))
+		if d < 0 {
+			return 0
+		}
+		return d
+	}
+}
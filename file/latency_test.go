@@ -0,0 +1,97 @@
+package file
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency Latency
+		min     time.Duration
+		max     time.Duration
+	}{
+		{name: "fixed", latency: Fixed(time.Second), min: time.Second, max: time.Second},
+		{name: "uniform", latency: Uniform(time.Second, 2*time.Second), min: time.Second, max: 2 * time.Second},
+		{name: "normal", latency: Normal(time.Second, 100*time.Millisecond), min: 0, max: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r1, r2 := rand.New(rand.NewSource(1)), rand.New(rand.NewSource(1))
+			for i := 0; i < 10; i++ {
+				d := tt.latency(r1)
+				assert.Equal(t, d, tt.latency(r2))
+				assert.True(t, d >= tt.min && d <= tt.max, "got %v", d)
+			}
+		})
+	}
+}
+
+func TestFS_Delay(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	clock := NewFakeClock(testTime)
+	fs.SetClock(clock)
+	fs.Config("/home/file1").Delay(200 * time.Millisecond)
+	fs.AddRule(NewRule("slow").Op("ReadFile").Latency(Uniform(time.Second, 2*time.Second)).Seed(1))
+
+	_, err := fs.Stat("/home/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, testTime.Add(200*time.Millisecond), clock.Now())
+
+	_, err = fs.ReadFile("/home/dir/file2")
+	assert.NoError(t, err)
+	d := fs.CallsTo("Fault")[0].Args["delay"].(time.Duration)
+	assert.Equal(t, testTime.Add(200*time.Millisecond+d), clock.Now())
+}
+
+func TestFS_Context(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		cancel   bool
+		wantErr  error
+		wantTime time.Duration
+	}{
+		{name: "inTime", timeout: time.Second, wantTime: 200 * time.Millisecond},
+		{name: "deadline", timeout: 50 * time.Millisecond, wantErr: context.DeadlineExceeded, wantTime: 50 * time.Millisecond},
+		{name: "canceled", timeout: time.Second, cancel: true, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			clock := NewFakeClock(testTime)
+			fs.SetClock(clock)
+			fs.Config("/home/file1").Delay(200 * time.Millisecond)
+			ctx, cancel := clock.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			_, err := fs.StatContext(ctx, "/home/file1")
+			assert.Equal(t, tt.wantErr, err)
+			_, err = fs.ReadFileContext(ctx, "/home/file1")
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+			}
+			assert.True(t, clock.Now().Sub(testTime) >= tt.wantTime)
+			if tt.wantErr != nil {
+				assert.Equal(t, testTime.Add(tt.wantTime), clock.Now())
+			}
+		})
+	}
+}
+
+func TestFS_ContextRealClock(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.Config("/home/file1").Delay(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := fs.StatContext(ctx, "/home/file1")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
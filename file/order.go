@@ -0,0 +1,123 @@
+package file
+
+import (
+	"io"
+	iofs "io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// DirOrder arranges the entries of a directory as returned by File.Readdir,
+// File.Readdirnames and File.ReadDir. ReadDir always sorts by name like ioutil.ReadDir.
+type DirOrder func(entries []os.FileInfo)
+
+// Sorted returns a DirOrder which sorts entries by name (default).
+func Sorted() DirOrder {
+	return func(entries []os.FileInfo) {
+		sortByName(entries)
+	}
+}
+
+// Shuffle returns a DirOrder which puts entries in a random order generated
+// from seed. Use it to find code which depends on the order of a directory.
+func Shuffle(seed int64) DirOrder {
+	r := rand.New(rand.NewSource(seed))
+	return func(entries []os.FileInfo) {
+		sortByName(entries)
+		r.Shuffle(len(entries), func(i, j int) {
+			entries[i], entries[j] = entries[j], entries[i]
+		})
+	}
+}
+
+// WithDirOrder is an option to set the order of raw directory entries.
+func WithDirOrder(o DirOrder) Option {
+	return func(fs *FS) {
+		fs.SetDirOrder(o)
+	}
+}
+
+// SetDirOrder sets the order of raw directory entries.
+func (fs *FS) SetDirOrder(o DirOrder) {
+	fs.dirOrder = o
+}
+
+func sortByName(entries []os.FileInfo) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+}
+
+// Readdir reads the contents of the directory and returns up to n entries in
+// the order of the FS (see WithDirOrder). If n <= 0, all remaining entries
+// are returned.
+func (f *File) Readdir(n int) ([]os.FileInfo, error) {
+	resp := f.run("Readdir", "readdirent", testdouble.Args{"n": n, "count": 0}, func(oc *OpContext) {
+		if oc.Err = f.check("readdirent", true); oc.Err != nil {
+			return
+		}
+		if !f.fi.IsDir() {
+			oc.Err = syscall.ENOTDIR
+			return
+		}
+		entries, err := f.readdir(n)
+		oc.Result = entries
+		oc.Args["count"] = len(entries)
+		oc.Err = err
+	})
+	entries, _ := resp.Result.([]os.FileInfo)
+	return entries, resp.Err
+}
+
+func (f *File) readdir(n int) ([]os.FileInfo, error) {
+	if f.dirEntries == nil {
+		f.fs.touchAccess(f.fi)
+		entries := []os.FileInfo{}
+		for name, v := range f.fs.getDirEntries(f.path) {
+			entries = append(entries, v)
+			f.fs.access(filepath.Join(f.path, name))
+		}
+		order := f.fs.dirOrder
+		if order == nil {
+			order = Sorted()
+		}
+		order(entries)
+		f.dirEntries = entries
+	}
+	rest := f.dirEntries[f.dirOffset:]
+	if n <= 0 {
+		f.dirOffset += len(rest)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return rest, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	f.dirOffset += n
+	return rest[:n], nil
+}
+
+// Readdirnames is like Readdir but returns names only.
+func (f *File) Readdirnames(n int) ([]string, error) {
+	entries, err := f.Readdir(n)
+	names := make([]string, len(entries))
+	for i, v := range entries {
+		names[i] = v.Name()
+	}
+	return names, err
+}
+
+// ReadDir is like Readdir but returns directory entries like os.File.ReadDir.
+func (f *File) ReadDir(n int) ([]iofs.DirEntry, error) {
+	entries, err := f.Readdir(n)
+	dirEntries := make([]iofs.DirEntry, len(entries))
+	for i, v := range entries {
+		dirEntries[i] = iofs.FileInfoToDirEntry(v)
+	}
+	return dirEntries, err
+}
@@ -0,0 +1,98 @@
+package file
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func orderStubs() map[string]*FileInfo {
+	stubs := map[string]*FileInfo{"/dir": {FIsDir: true}}
+	for _, name := range []string{"e", "b", "d", "a", "c", "f", "h", "g"} {
+		stubs["/dir/"+name] = &FileInfo{}
+	}
+	return stubs
+}
+
+func names(entries []os.FileInfo) []string {
+	retval := []string{}
+	for _, v := range entries {
+		retval = append(retval, v.Name())
+	}
+	return retval
+}
+
+var sortedNames = []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+func TestFS_ReadDir_sorted(t *testing.T) {
+	for _, opt := range []Option{WithDirOrder(Sorted()), WithDirOrder(Shuffle(1))} {
+		fs := newFS(t, orderStubs(), opt)
+		for i := 0; i < 10; i++ {
+			entries, err := fs.ReadDir("/dir")
+			assert.NoError(t, err)
+			assert.Equal(t, sortedNames, names(entries))
+		}
+	}
+}
+
+func TestFile_Readdir(t *testing.T) {
+	fs := newFS(t, orderStubs())
+	f, err := fs.Open("/dir")
+	assert.NoError(t, err)
+
+	entries, err := f.Readdir(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, names(entries))
+	got, err := f.Readdirnames(-1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d", "e", "f", "g", "h"}, got)
+	_, err = f.Readdir(1)
+	assert.Equal(t, io.EOF, err)
+	entries, err = f.Readdir(-1)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	f, _ = fs.Open("/dir/a")
+	_, err = f.Readdir(-1)
+	assert.Error(t, err)
+}
+
+func TestFile_ReadDir(t *testing.T) {
+	fs := newFS(t, orderStubs(), WithDirOrder(Shuffle(7)))
+	f, err := fs.Open("/dir")
+	assert.NoError(t, err)
+	entries, err := f.ReadDir(2)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	rest, err := f.ReadDir(-1)
+	assert.NoError(t, err)
+	got := []string{}
+	for _, v := range append(entries, rest...) {
+		assert.False(t, v.IsDir())
+		got = append(got, v.Name())
+	}
+	assert.ElementsMatch(t, sortedNames, got)
+	assert.NotEqual(t, sortedNames, got, "ReadDir must use the order of the FS")
+	_, err = f.ReadDir(1)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFile_Readdir_shuffle(t *testing.T) {
+	readAll := func(fs *FS) []string {
+		f, err := fs.Open("/dir")
+		assert.NoError(t, err)
+		got, err := f.Readdirnames(-1)
+		assert.NoError(t, err)
+		return got
+	}
+
+	fs1 := newFS(t, orderStubs(), WithDirOrder(Shuffle(7)))
+	fs2 := newFS(t, orderStubs(), WithDirOrder(Shuffle(7)))
+	first := readAll(fs1)
+	assert.ElementsMatch(t, sortedNames, first)
+	assert.NotEqual(t, sortedNames, first)
+	assert.Equal(t, first, readAll(fs2), "same seed must give the same order")
+	assert.NotEqual(t, first, readAll(fs1), "order must change between reads")
+}
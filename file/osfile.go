@@ -0,0 +1,89 @@
+package file
+
+import (
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// osFile is a Handle which delegates to a real file and passes its calls to
+// an observer.
+type osFile struct {
+	f       *os.File
+	name    string
+	path    string
+	observe func(c testdouble.Call)
+}
+
+// NewOsFile returns a Handle which delegates to f, so real files can be used
+// where a Handle is expected. name is returned by Name. observe is called
+// after each method call (ex: File.Read) with the call as it would be
+// recorded by the spy, it may be nil.
+func NewOsFile(f *os.File, name string, observe func(c testdouble.Call)) Handle {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		path = name
+	}
+	return &osFile{f: f, name: name, path: path, observe: observe}
+}
+
+// call passes the call of method op to the observer.
+func (f *osFile) call(op string, args testdouble.Args, err error) error {
+	if f.observe != nil {
+		f.observe(testdouble.Call{Op: "File." + op, Path: f.path, Args: args, Err: err, Time: time.Now()})
+	}
+	return err
+}
+
+// Name returns the name of the file as presented to NewOsFile.
+func (f *osFile) Name() string { return f.name }
+
+func (f *osFile) Read(b []byte) (int, error) {
+	n, err := f.f.Read(b)
+	return n, f.call("Read", testdouble.Args{"len": len(b), "n": n}, err)
+}
+
+func (f *osFile) Write(b []byte) (int, error) {
+	n, err := f.f.Write(b)
+	return n, f.call("Write", testdouble.Args{"len": len(b), "n": n}, err)
+}
+
+func (f *osFile) Seek(offset int64, whence int) (int64, error) {
+	return f.f.Seek(offset, whence)
+}
+
+func (f *osFile) Stat() (os.FileInfo, error) {
+	return f.f.Stat()
+}
+
+func (f *osFile) Readdir(n int) ([]os.FileInfo, error) {
+	entries, err := f.f.Readdir(n)
+	return entries, f.call("Readdir", testdouble.Args{"n": n, "count": len(entries)}, err)
+}
+
+// Readdirnames is like Readdir but returns names only.
+func (f *osFile) Readdirnames(n int) ([]string, error) {
+	entries, err := f.Readdir(n)
+	names := make([]string, len(entries))
+	for i, v := range entries {
+		names[i] = v.Name()
+	}
+	return names, err
+}
+
+// ReadDir is like Readdir but returns directory entries.
+func (f *osFile) ReadDir(n int) ([]iofs.DirEntry, error) {
+	entries, err := f.f.ReadDir(n)
+	return entries, f.call("Readdir", testdouble.Args{"n": n, "count": len(entries)}, err)
+}
+
+func (f *osFile) Sync() error {
+	return f.call("Sync", nil, f.f.Sync())
+}
+
+func (f *osFile) Close() error {
+	return f.call("Close", nil, f.f.Close())
+}
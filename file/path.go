@@ -0,0 +1,124 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// resolve returns the cleaned absolute form of p. Relative paths are
+// resolved against the current working directory.
+func (fs *FS) resolve(p string) string {
+	if p == "" {
+		return ""
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(fs.getwd(), p)
+	}
+	return filepath.Clean(p)
+}
+
+// checkName returns the error of the OS for the parts of name which resolve
+// cleans away: the directories of name must exist even if .. leaves them
+// (ex: /a in /a/../b). If trailing is true, a name ending with a separator
+// must not be a file.
+func (fs *FS) checkName(name string, trailing bool) error {
+	sep := string(os.PathSeparator)
+	if name == "" {
+		return nil
+	}
+	abs := fs.absName(name)
+	if filepath.Clean(abs) == abs {
+		return nil
+	}
+	dir := sep
+	elems := strings.Split(strings.TrimRight(abs, sep), sep)
+	for _, e := range elems[:len(elems)-1] {
+		switch e {
+		case "", ".":
+			continue
+		case "..":
+			dir = filepath.Dir(dir)
+			continue
+		}
+		dir = filepath.Join(dir, e)
+		v, ok := fs.PathStubs[dir]
+		switch {
+		case !ok:
+			return syscall.ENOENT
+		case v.Error != nil:
+			return v.Error
+		case !v.IsDir():
+			return syscall.ENOTDIR
+		}
+	}
+	if trailing && strings.HasSuffix(name, sep) {
+		if v, ok := fs.PathStubs[fs.resolve(name)]; ok && v.Error == nil && !v.IsDir() {
+			return syscall.ENOTDIR
+		}
+	}
+	return nil
+}
+
+// absName returns name joined to the current working directory if it is
+// relative. Unlike resolve, it does not clean name.
+func (fs *FS) absName(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return strings.TrimSuffix(fs.getwd(), string(os.PathSeparator)) + string(os.PathSeparator) + name
+}
+
+// Resolve returns the cleaned absolute path of p as used to look up files.
+func (fs *FS) Resolve(p string) string {
+	return fs.resolve(p)
+}
+
+func (fs *FS) getwd() string {
+	if fs.cwd != "" {
+		return fs.cwd
+	}
+	if fs.AbsPathPrefix != "" {
+		return filepath.Clean(string(os.PathSeparator) + fs.AbsPathPrefix)
+	}
+	return string(os.PathSeparator)
+}
+
+// isWithin returns true if path is root or below root.
+func isWithin(path string, root string) bool {
+	if path == root || root == string(os.PathSeparator) {
+		return true
+	}
+	return strings.HasPrefix(path, root+string(os.PathSeparator))
+}
+
+// Chdir is a stub for os.Chdir
+func (fs *FS) Chdir(dir string) error {
+	req := &Request{Op: "Chdir", Path: fs.resolve(dir)}
+	resp := fs.run(req, func(oc *OpContext) {
+		if oc.Err = fs.checkName(dir, true); oc.Err != nil {
+			return
+		}
+		if oc.Err = fs.requireDir(oc.Path, "Chdir"); oc.Err == nil {
+			fs.cwd = oc.Path
+		}
+	})
+	return pathError("chdir", req.Path, resp.Err)
+}
+
+// Getwd is a stub for os.Getwd
+func (fs *FS) Getwd() (string, error) {
+	return fs.getwd(), nil
+}
+
+// Abs is a stub for filepath.Abs
+func (fs *FS) Abs(p string) (string, error) {
+	if fs.AbsPathError != nil {
+		return "", fs.AbsPathError
+	}
+	if p == "" {
+		return fs.getwd(), nil
+	}
+	return fs.resolve(p), nil
+}
@@ -0,0 +1,149 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_resolve(t *testing.T) {
+	tests := []struct {
+		name string
+		cwd  string
+		p    string
+		want string
+	}{
+		{name: "empty", cwd: "/home", p: "", want: ""},
+		{name: "absolute", cwd: "/home", p: "/etc/hosts", want: "/etc/hosts"},
+		{name: "relative", cwd: "/home/maggy", p: "config.yaml", want: "/home/maggy/config.yaml"},
+		{name: "dot", cwd: "/home/maggy", p: ".", want: "/home/maggy"},
+		{name: "dotDot", cwd: "/home/maggy", p: "./a/../b", want: "/home/maggy/b"},
+		{name: "aboveRoot", cwd: "/home", p: "../../..", want: "/"},
+		{name: "duplicateSlashes", cwd: "/", p: "//home///maggy", want: "/home/maggy"},
+		{name: "trailingSlash", cwd: "/", p: "/home/maggy/", want: "/home/maggy"},
+		{name: "defaultCwd", p: "home", want: "/home"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &FS{cwd: tt.cwd}
+			assert.Equal(t, tt.want, fs.resolve(tt.p))
+		})
+	}
+}
+
+func TestFS_checkName(t *testing.T) {
+	tests := []struct {
+		name     string
+		cwd      string
+		p        string
+		trailing bool
+		wantErr  error
+	}{
+		{name: "clean", p: "/home/missing/file"},
+		{name: "dotDot", p: "/home/maggy/b/../config.yaml"},
+		{name: "dotDotMissing", p: "/home/missing/../maggy", wantErr: syscall.ENOENT},
+		{name: "dotDotFile", p: "/home/maggy/config.yaml/../b", wantErr: syscall.ENOTDIR},
+		{name: "dotDotRelative", cwd: "/home/maggy", p: "../missing/../maggy", wantErr: syscall.ENOENT},
+		{name: "trailingDir", p: "/home/maggy/", trailing: true},
+		{name: "trailingFile", p: "/home/maggy/config.yaml/", trailing: true, wantErr: syscall.ENOTDIR},
+		{name: "trailingFileIgnored", p: "/home/maggy/config.yaml/"},
+		{name: "trailingMissing", p: "/home/maggy/missing/", trailing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.cwd = tt.cwd
+			assert.Equal(t, tt.wantErr, fs.checkName(tt.p, tt.trailing))
+		})
+	}
+}
+
+func TestFS_Chdir(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		wantWd  string
+		wantErr error
+	}{
+		{name: "absolute", dir: "/home/maggy", wantWd: "/home/maggy"},
+		{name: "relative", dir: "home/maggy/b/..", wantWd: "/home/maggy"},
+		{name: "errorNotExist", dir: "/invalid", wantWd: "/", wantErr: os.ErrNotExist},
+		{name: "errorNotADirectory", dir: "/home/maggy/config.yaml", wantWd: "/", wantErr: syscall.ENOTDIR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			err := fs.Chdir(tt.dir)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			wd, err := fs.Getwd()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantWd, wd)
+		})
+	}
+}
+
+func TestFS_relativeAccess(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	assert.NoError(t, fs.Chdir("/home/maggy"))
+
+	data, err := fs.ReadFile("config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("cfg"), data)
+
+	fi, err := fs.Stat("./b/../b/")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", fi.Name())
+	_, err = fs.Stat("./a/../b/")
+	assert.True(t, os.IsNotExist(err), "got %v", err)
+
+	entries, err := fs.ReadDir("b")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	got := []string{}
+	err = fs.Walk("b", func(path string, f os.FileInfo, err error) error {
+		got = append(got, path)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "b/file1"}, got)
+
+	assert.Equal(t, []byte("file1"), fs.Config("b/file1").Data())
+}
+
+func TestFS_Abs(t *testing.T) {
+	tests := []struct {
+		name          string
+		cwd           string
+		absPathPrefix string
+		absPathError  error
+		p             string
+		want          string
+		wantErr       bool
+	}{
+		{name: "absolute", cwd: "/home/maggy", p: "/etc/../etc/hosts", want: "/etc/hosts"},
+		{name: "relative", cwd: "/home/maggy", p: "b/file1", want: "/home/maggy/b/file1"},
+		{name: "empty", cwd: "/home/maggy", p: "", want: "/home/maggy"},
+		{name: "absPathPrefix", absPathPrefix: "/home", p: "maggy", want: "/home/maggy"},
+		{name: "cwdOverridesAbsPathPrefix", cwd: "/home/maggy", absPathPrefix: "/home", p: "b", want: "/home/maggy/b"},
+		{name: "error", absPathError: errors.New("test"), p: "b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &FS{cwd: tt.cwd, AbsPathPrefix: tt.absPathPrefix, AbsPathError: tt.absPathError}
+			got, err := fs.Abs(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FS.Abs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package file
+
+import "syscall"
+
+// Quota limits the capacity of a FS.
+type Quota struct {
+	// Bytes is the capacity for file data in bytes (0 means unlimited)
+	Bytes int64
+	// Inodes is the maximum number of files and directories including the
+	// root directory (0 means unlimited)
+	Inodes int64
+	// Err is returned if the capacity is exceeded (default: ENOSPC, use
+	// EDQUOT for a user quota)
+	Err error
+}
+
+// DiskUsage is returned by Statfs. Capacities are 0 if unlimited.
+type DiskUsage struct {
+	Bytes      int64
+	UsedBytes  int64
+	FreeBytes  int64
+	Inodes     int64
+	UsedInodes int64
+	FreeInodes int64
+}
+
+// WithQuota is an option to limit the capacity of a FS.
+func WithQuota(q Quota) Option {
+	return func(fs *FS) {
+		fs.SetQuota(q)
+	}
+}
+
+// SetQuota limits the capacity of the FS. Writes, creations, appends and
+// truncates which exceed it fail with q.Err. A write which exceeds it writes
+// the bytes up to the capacity.
+func (fs *FS) SetQuota(q Quota) {
+	fs.quota = &q
+}
+
+// Statfs returns the disk usage of the FS (see statfs(2)). name must exist.
+func (fs *FS) Statfs(name string) (DiskUsage, error) {
+	req := &Request{Op: "Statfs", Path: fs.resolve(name)}
+	resp := fs.run(req, func(oc *OpContext) {
+		if _, oc.Err = fs.getFile(oc.Path, "Statfs"); oc.Err != nil {
+			return
+		}
+		u := DiskUsage{}
+		u.UsedBytes, u.UsedInodes = fs.usage()
+		if fs.quota != nil {
+			u.Bytes, u.Inodes = fs.quota.Bytes, fs.quota.Inodes
+		}
+		if u.Bytes > 0 && u.Bytes > u.UsedBytes {
+			u.FreeBytes = u.Bytes - u.UsedBytes
+		}
+		if u.Inodes > 0 && u.Inodes > u.UsedInodes {
+			u.FreeInodes = u.Inodes - u.UsedInodes
+		}
+		oc.Result = u
+	})
+	u, _ := resp.Result.(DiskUsage)
+	return u, pathError("statfs", name, resp.Err)
+}
+
+// usageTotal is the running total of used bytes and inodes.
+type usageTotal struct {
+	bytes  int64
+	inodes int64
+}
+
+// usage returns the used bytes and inodes. They are counted once and then
+// kept up to date by the operations of the FS.
+func (fs *FS) usage() (bytes int64, inodes int64) {
+	if fs.used == nil {
+		fs.used = &usageTotal{}
+		for _, fi := range fs.PathStubs {
+			fs.used.inodes++
+			if !fi.IsDir() {
+				fs.used.bytes += sizeOf(fi)
+			}
+		}
+	}
+	return fs.used.bytes, fs.used.inodes
+}
+
+// charge adds bytes and inodes to the running total.
+func (fs *FS) charge(bytes int64, inodes int64) {
+	if fs.used != nil {
+		fs.used.bytes += bytes
+		fs.used.inodes += inodes
+	}
+}
+
+// resized charges the change of the size of fi which had old bytes.
+func (fs *FS) resized(fi *FileInfo, old int64) {
+	if !fi.IsDir() {
+		fs.charge(sizeOf(fi)-old, 0)
+	}
+}
+
+// deleteStub removes path from the FS and its usage.
+func (fs *FS) deleteStub(path string) {
+	fi, ok := fs.PathStubs[path]
+	if !ok {
+		return
+	}
+	delete(fs.PathStubs, path)
+	fs.markRemoved(path)
+	if fi.IsDir() {
+		fs.charge(0, -1)
+	} else {
+		fs.charge(-sizeOf(fi), -1)
+	}
+}
+
+// sizeOf returns the size of a file. Fixtures may have a size without data.
+func sizeOf(fi *FileInfo) int64 {
+	if n := int64(len(fi.Data)); n > fi.FSize {
+		return n
+	}
+	return fi.FSize
+}
+
+func (fs *FS) quotaError() error {
+	if fs.quota.Err != nil {
+		return fs.quota.Err
+	}
+	return syscall.ENOSPC
+}
+
+// allocInode returns an error if no inode is left for a new file.
+func (fs *FS) allocInode() error {
+	if fs.quota == nil || fs.quota.Inodes <= 0 {
+		return nil
+	}
+	if _, inodes := fs.usage(); inodes >= fs.quota.Inodes {
+		return fs.quotaError()
+	}
+	return nil
+}
+
+// allocBytes returns how many bytes fi may hold if it shall hold size bytes
+// and an error if it is less than size.
+func (fs *FS) allocBytes(fi *FileInfo, size int64) (int64, error) {
+	if fs.quota == nil || fs.quota.Bytes <= 0 {
+		return size, nil
+	}
+	used, _ := fs.usage()
+	avail := fs.quota.Bytes - used + sizeOf(fi)
+	if size <= avail {
+		return size, nil
+	}
+	if avail < 0 {
+		avail = 0
+	}
+	return avail, fs.quotaError()
+}
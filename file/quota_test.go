@@ -0,0 +1,154 @@
+package file
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Quota(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    Quota
+		op       func(fs *FS) error
+		wantErr  error
+		wantPath string
+		wantData string
+	}{
+		{
+			name:     "writeFile",
+			quota:    Quota{Bytes: 28},
+			op:       func(fs *FS) error { return fs.WriteFile("/home/new", []byte("0123456789"), 0644) },
+			wantPath: "/home/new",
+			wantData: "0123456789",
+		},
+		{
+			name:     "writeFilePartial",
+			quota:    Quota{Bytes: 23},
+			op:       func(fs *FS) error { return fs.WriteFile("/home/new", []byte("0123456789"), 0644) },
+			wantErr:  syscall.ENOSPC,
+			wantPath: "/home/new",
+			wantData: "01234",
+		},
+		{
+			name:     "overwriteFreesSpace",
+			quota:    Quota{Bytes: 19},
+			op:       func(fs *FS) error { return fs.WriteFile("/home/file1", []byte("012345"), 0644) },
+			wantPath: "/home/file1",
+			wantData: "012345",
+		},
+		{
+			name:  "append",
+			quota: Quota{Bytes: 21, Err: syscall.EDQUOT},
+			op: func(fs *FS) error {
+				f, err := fs.OpenFile("/home/file1", os.O_WRONLY|os.O_APPEND, 0)
+				if err != nil {
+					return err
+				}
+				n, err := f.WriteString("0123")
+				if n != 3 {
+					return errors.New("no partial write")
+				}
+				return err
+			},
+			wantErr:  syscall.EDQUOT,
+			wantPath: "/home/file1",
+			wantData: "file1012",
+		},
+		{
+			name:  "writePastEOF",
+			quota: Quota{Bytes: 18},
+			op: func(fs *FS) error {
+				f, err := fs.OpenFile("/home/file1", os.O_WRONLY, 0)
+				if err != nil {
+					return err
+				}
+				f.Seek(100, io.SeekStart)
+				_, err = f.WriteString("0123")
+				return err
+			},
+			wantErr:  syscall.ENOSPC,
+			wantPath: "/home/file1",
+			wantData: "file1",
+		},
+		{
+			name:     "truncateGrow",
+			quota:    Quota{Bytes: 20, Err: syscall.EDQUOT},
+			op:       func(fs *FS) error { return fs.Truncate("/home/file1", 10) },
+			wantErr:  syscall.EDQUOT,
+			wantPath: "/home/file1",
+			wantData: "file1",
+		},
+		{
+			name:     "truncateShrink",
+			quota:    Quota{Bytes: 18},
+			op:       func(fs *FS) error { return fs.Truncate("/home/file1", 2) },
+			wantPath: "/home/file1",
+			wantData: "fi",
+		},
+		{
+			name:    "inodes",
+			quota:   Quota{Inodes: 11},
+			op:      func(fs *FS) error { return fs.Mkdir("/home/new", 0755) },
+			wantErr: syscall.ENOSPC,
+		},
+		{
+			name:    "inodesCreate",
+			quota:   Quota{Inodes: 11},
+			op:      func(fs *FS) error { _, err := fs.Create("/home/new"); return err },
+			wantErr: syscall.ENOSPC,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.SetQuota(tt.quota)
+			err := tt.op(fs)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.wantPath != "" {
+				assert.Equal(t, tt.wantData, string(fs.PathStubs[tt.wantPath].Data))
+			}
+		})
+	}
+}
+
+func TestFS_Statfs(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.SetQuota(Quota{Bytes: 100, Inodes: 20})
+	u, err := fs.Statfs("/home")
+	assert.NoError(t, err)
+	assert.Equal(t, DiskUsage{Bytes: 100, UsedBytes: 18, FreeBytes: 82, Inodes: 20, UsedInodes: 11, FreeInodes: 9}, u)
+
+	_, err = fs.Statfs("/missing")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFS_usage(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.SetQuota(Quota{Bytes: 100, Inodes: 20})
+	fs.usage()
+	assert.NoError(t, fs.WriteFile("/home/new", []byte("0123456789"), 0644))
+	assert.NoError(t, fs.Truncate("/home/file1", 8))
+	assert.NoError(t, fs.Mkdir("/home/sub", 0755))
+	assert.NoError(t, fs.WriteFile("/home/sub/file", []byte("01"), 0644))
+	assert.NoError(t, fs.Rename("/home/new", "/home/file1"))
+	f, err := fs.Create("/home/file2")
+	assert.NoError(t, err)
+	_, err = f.WriteString("0123")
+	assert.NoError(t, err)
+	assert.NoError(t, fs.RemoveAll("/home/sub"))
+
+	bytes, inodes := fs.usage()
+	fs.used = nil
+	wantBytes, wantInodes := fs.usage()
+	assert.Equal(t, wantBytes, bytes)
+	assert.Equal(t, wantInodes, inodes)
+}
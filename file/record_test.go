@@ -0,0 +1,62 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_record(t *testing.T) {
+	clock := NewFakeClock(testTime)
+	fs := newFS(t, map[string]*FileInfo{
+		"/home":       {FIsDir: true},
+		"/home/file1": {Data: []byte("file1")},
+	}, WithClock(clock))
+	td := fs.TestDouble
+	assert.NoError(t, fs.Chdir("/home"))
+
+	_, err := fs.Stat("file1")
+	assert.NoError(t, err)
+	clock.Advance(time.Second)
+	_, err = fs.ReadFile("./file1")
+	assert.NoError(t, err)
+	_, err = fs.ReadFile("missing")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.NoError(t, fs.WriteFile("/home/out", []byte("ok"), 0600))
+	f, err := fs.OpenFile("/home/out", os.O_RDWR, 0)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("data"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	type call struct {
+		Op   string
+		Path string
+		Args testdouble.Args
+		Err  error
+	}
+	got := []call{}
+	for _, c := range td.Calls() {
+		got = append(got, call{Op: c.Op, Path: c.Path, Args: c.Args, Err: c.Err})
+	}
+	assert.Equal(t, []call{
+		{Op: "Chdir", Path: "/home"},
+		{Op: "Stat", Path: "/home/file1"},
+		{Op: "ReadFile", Path: "/home/file1", Args: testdouble.Args{"size": 5}},
+		{Op: "ReadFile", Path: "/home/missing", Err: &os.PathError{Op: "open", Path: "missing", Err: os.ErrNotExist}},
+		{Op: "WriteFile", Path: "/home/out", Args: testdouble.Args{"perm": os.FileMode(0600), "size": 2}},
+		{Op: "OpenFile", Path: "/home/out", Args: testdouble.Args{"flag": os.O_RDWR, "perm": os.FileMode(0)}},
+		{Op: "File.Write", Path: "/home/out", Args: testdouble.Args{"len": 4, "n": 4}},
+		{Op: "File.Close", Path: "/home/out"},
+	}, got)
+
+	calls := td.Calls()
+	assert.Equal(t, testTime, calls[1].Time)
+	assert.Equal(t, testTime.Add(time.Second), calls[2].Time)
+	assert.Equal(t, 2, td.CallCount("ReadFile", ""))
+	assert.Equal(t, 1, td.CallCount("ReadFile", "/home/file1"))
+}
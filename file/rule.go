@@ -0,0 +1,332 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Rule injects faults into operations of a FS. A rule fires for a call if all
+// of its conditions are met. Firing rules are recorded in the spy as calls of
+// op "Fault".
+type Rule struct {
+	name        string
+	ops         map[string]bool
+	pattern     string
+	nth         int
+	every       int
+	after       int
+	probability float64
+	rand        *rand.Rand
+	once        sync.Once
+	// randMu serializes corruptions and latencies, they may call Read of
+	// rand which lockedSource does not protect
+	randMu    sync.Mutex
+	err       error
+	latency   Latency
+	transform func([]byte) []byte
+	reads     bool
+	// disabled, count and fired are updated atomically, rules fire for
+	// concurrent calls
+	disabled int32
+	count    int64
+	fired    int64
+}
+
+// NewRule creates a new rule. name is used in the spy and to remove the rule.
+func NewRule(name string) *Rule {
+	return &Rule{name: name, probability: 1}
+}
+
+// Name returns the name of the rule.
+func (r *Rule) Name() string { return r.name }
+
+// Op restricts the rule to the given operations (ex: ReadFile, File.Read).
+func (r *Rule) Op(ops ...string) *Rule {
+	if r.ops == nil {
+		r.ops = make(map[string]bool)
+	}
+	for _, op := range ops {
+		r.ops[op] = true
+	}
+	return r
+}
+
+// Path restricts the rule to paths matching pattern (see MatchPattern).
+func (r *Rule) Path(pattern string) *Rule {
+	r.pattern = pattern
+	return r
+}
+
+// Nth lets the rule fire on the nth matching call only (starting with 1).
+func (r *Rule) Nth(n int) *Rule {
+	r.nth = n
+	return r
+}
+
+// Every lets the rule fire on every k-th matching call.
+func (r *Rule) Every(k int) *Rule {
+	r.every = k
+	return r
+}
+
+// After lets the rule fire after the first n matching calls.
+func (r *Rule) After(n int) *Rule {
+	r.after = n
+	return r
+}
+
+// Probability lets the rule fire with probability p using a random source
+// seeded with seed.
+func (r *Rule) Probability(p float64, seed int64) *Rule {
+	r.probability = p
+	r.rand = newRand(seed)
+	return r
+}
+
+// Error lets the rule return err.
+func (r *Rule) Error(err error) *Rule {
+	r.err = err
+	return r
+}
+
+// Delay lets the rule delay the operation by d (using the clock of the FS).
+func (r *Rule) Delay(d time.Duration) *Rule {
+	return r.Latency(Fixed(d))
+}
+
+// Latency lets the rule delay the operation by a duration of the
+// distribution l (using the clock of the FS).
+func (r *Rule) Latency(l Latency) *Rule {
+	r.latency = l
+	return r
+}
+
+// Seed seeds the random source of the rule (see Probability and Latency).
+func (r *Rule) Seed(seed int64) *Rule {
+	r.rand = newRand(seed)
+	return r
+}
+
+// Transform lets the rule transform data read or written by the operation.
+func (r *Rule) Transform(fn func([]byte) []byte) *Rule {
+	r.transform = fn
+	return r
+}
+
+// Corrupt lets the rule corrupt data read by ReadFile and File.Read. The
+// stored data is not changed. The rule only matches reads. File.Read
+// corrupts the whole content once, the read the rule fires for and later
+// reads of the handle return the corrupted content.
+func (r *Rule) Corrupt(c Corruption) *Rule {
+	r.reads = true
+	return r.Transform(func(data []byte) []byte {
+		r.randMu.Lock()
+		defer r.randMu.Unlock()
+		return c(data, r.random())
+	})
+}
+
+// Enable enables the rule.
+func (r *Rule) Enable() { atomic.StoreInt32(&r.disabled, 0) }
+
+// Disable disables the rule. Disabled rules do not count calls.
+func (r *Rule) Disable() { atomic.StoreInt32(&r.disabled, 1) }
+
+// Enabled returns true if the rule is enabled.
+func (r *Rule) Enabled() bool { return atomic.LoadInt32(&r.disabled) == 0 }
+
+// Fired returns how often the rule fired.
+func (r *Rule) Fired() int { return int(atomic.LoadInt64(&r.fired)) }
+
+// Reset resets the counters of the rule.
+func (r *Rule) Reset() {
+	atomic.StoreInt64(&r.count, 0)
+	atomic.StoreInt64(&r.fired, 0)
+}
+
+// String describes the rule.
+func (r *Rule) String() string {
+	return fmt.Sprintf("rule %q", r.name)
+}
+
+func (r *Rule) matches(op string, path string) bool {
+	if !r.Enabled() {
+		return false
+	}
+	if len(r.ops) > 0 && !r.ops[op] {
+		return false
+	}
+	if r.reads && op != "ReadFile" && op != "File.Read" {
+		return false
+	}
+	if r.pattern != "" && !MatchPattern(r.pattern, path) {
+		return false
+	}
+	return true
+}
+
+// fires counts a matching call and returns true if the rule fires.
+func (r *Rule) fires() bool {
+	count := atomic.AddInt64(&r.count, 1)
+	if r.nth > 0 && count != int64(r.nth) {
+		return false
+	}
+	if r.every > 0 && count%int64(r.every) != 0 {
+		return false
+	}
+	if r.after > 0 && count <= int64(r.after) {
+		return false
+	}
+	if r.probability < 1 && r.random().Float64() >= r.probability {
+		return false
+	}
+	atomic.AddInt64(&r.fired, 1)
+	return true
+}
+
+func (r *Rule) random() *rand.Rand {
+	r.once.Do(func() {
+		if r.rand == nil {
+			r.rand = newRand(0)
+		}
+	})
+	return r.rand
+}
+
+// lockedSource is a rand.Source which can be used by concurrent calls.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func newRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{src: rand.NewSource(seed)})
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.(rand.Source64).Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// WithRules is an option to add fault injection rules.
+func WithRules(rules ...*Rule) Option {
+	return func(fs *FS) {
+		fs.AddRule(rules...)
+	}
+}
+
+// AddRule adds fault injection rules. Rules are applied in the order they
+// were added: delays add up, transforms are chained and the first error is
+// returned.
+func (fs *FS) AddRule(rules ...*Rule) {
+	fs.rules = append(fs.rules, rules...)
+}
+
+// RemoveRule removes the rules named name.
+func (fs *FS) RemoveRule(name string) {
+	rules := []*Rule{}
+	for _, r := range fs.rules {
+		if r.name != name {
+			rules = append(rules, r)
+		}
+	}
+	fs.rules = rules
+}
+
+// Rule returns the rule named name or nil.
+func (fs *FS) Rule(name string) *Rule {
+	for _, r := range fs.rules {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// inject applies the rules matching op and path. It returns the chained
+// transforms of the firing rules and the first error.
+func (fs *FS) inject(op string, path string) (func([]byte) []byte, error) {
+	return fs.injectContext(context.Background(), op, path)
+}
+
+// injectContext is like inject, but delays stop with ctx.Err() if ctx is
+// done.
+func (fs *FS) injectContext(ctx context.Context, op string, path string) (func([]byte) []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if fi, ok := fs.PathStubs[path]; ok {
+		if fi.Delay > 0 {
+			if err := fs.sleep(ctx, fi.Delay); err != nil {
+				return nil, err
+			}
+		}
+		if fi.Transient != nil {
+			if err := fi.Transient.attempt(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var transforms []func([]byte) []byte
+	var err error
+	for _, r := range fs.rules {
+		if !r.matches(op, path) || !r.fires() {
+			continue
+		}
+		args := testdouble.Args{"rule": r.name, "op": op}
+		if r.latency != nil {
+			r.randMu.Lock()
+			d := r.latency(r.random())
+			r.randMu.Unlock()
+			args["delay"] = d
+			if err := fs.sleep(ctx, d); err != nil {
+				fs.record("Fault", path, err, args)
+				return nil, err
+			}
+		}
+		if r.transform != nil {
+			args["transform"] = true
+			transforms = append(transforms, r.transform)
+		}
+		fs.record("Fault", path, r.err, args)
+		if err == nil {
+			err = r.err
+		}
+	}
+	if len(transforms) == 0 {
+		return nil, err
+	}
+	return func(data []byte) []byte {
+		for _, fn := range transforms {
+			data = fn(data)
+		}
+		return data
+	}, err
+}
+
+// applyTransform applies fn to a copy of data. The stored data stays intact.
+func applyTransform(fn func([]byte) []byte, data []byte) []byte {
+	if fn == nil {
+		return data
+	}
+	return fn(append([]byte{}, data...))
+}
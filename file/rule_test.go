@@ -0,0 +1,182 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Rule(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  *Rule
+		path  string
+		calls int
+		want  []bool
+	}{
+		{
+			name:  "always",
+			rule:  NewRule("r"),
+			path:  "/home/file1",
+			calls: 3,
+			want:  []bool{true, true, true},
+		},
+		{
+			name:  "otherOp",
+			rule:  NewRule("r").Op("Stat"),
+			path:  "/home/file1",
+			calls: 2,
+			want:  []bool{false, false},
+		},
+		{
+			name:  "path",
+			rule:  NewRule("r").Path("/home/**"),
+			path:  "/home/dir/file2",
+			calls: 1,
+			want:  []bool{true},
+		},
+		{
+			name:  "otherPath",
+			rule:  NewRule("r").Path("/home/dir/*"),
+			path:  "/home/file1",
+			calls: 1,
+			want:  []bool{false},
+		},
+		{
+			name:  "nth",
+			rule:  NewRule("r").Op("ReadFile").Nth(2),
+			path:  "/home/file1",
+			calls: 4,
+			want:  []bool{false, true, false, false},
+		},
+		{
+			name:  "every",
+			rule:  NewRule("r").Every(2),
+			path:  "/home/file1",
+			calls: 4,
+			want:  []bool{false, true, false, true},
+		},
+		{
+			name:  "after",
+			rule:  NewRule("r").After(2),
+			path:  "/home/file1",
+			calls: 4,
+			want:  []bool{false, false, true, true},
+		},
+		{
+			name:  "probability",
+			rule:  NewRule("r").Probability(0.5, 1),
+			path:  "/home/file1",
+			calls: 6,
+			want:  []bool{false, false, false, true, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.AddRule(tt.rule.Error(syscall.EIO))
+			got := []bool{}
+			for i := 0; i < tt.calls; i++ {
+				_, err := fs.ReadFile(tt.path)
+				got = append(got, errors.Is(err, syscall.EIO))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFS_RuleActions(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	clock := NewFakeClock(testTime)
+	fs.SetClock(clock)
+	fs.AddRule(
+		NewRule("slow").Op("ReadFile").Delay(time.Second),
+		NewRule("upper").Op("ReadFile", "File.Read").Transform(bytes.ToUpper),
+		NewRule("suffix").Op("ReadFile", "WriteFile").Transform(func(b []byte) []byte { return append(b, '!') }),
+	)
+
+	data, err := fs.ReadFile("/home/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, "FILE1!", string(data))
+	assert.Equal(t, testTime.Add(time.Second), clock.Now())
+
+	fs.Rule("upper").Disable()
+	fs.RemoveRule("slow")
+	data, _ = fs.ReadFile("/home/file1")
+	assert.Equal(t, "file1!", string(data))
+	assert.Equal(t, testTime.Add(time.Second), clock.Now())
+
+	fs.Rule("upper").Enable()
+	f, _ := fs.Open("/home/dir/file2")
+	data, err = ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "FILE2", string(data))
+
+	assert.NoError(t, fs.WriteFile("/home/new", []byte("new"), 0644))
+	assert.Equal(t, "new!", string(fs.PathStubs["/home/new"].Data))
+	assert.Equal(t, "file1", string(fs.PathStubs["/home/file1"].Data))
+}
+
+func TestFS_RuleRecorded(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	rule := NewRule("eio").Op("Stat").Nth(2).Error(syscall.EIO)
+	fs.AddRule(rule)
+	fs.Stat("/home/file1")
+	_, err := fs.Stat("/home/file1")
+	assert.Equal(t, syscall.EIO, err)
+	assert.Equal(t, 1, rule.Fired())
+
+	calls := fs.CallsTo("Fault")
+	if assert.Len(t, calls, 1) {
+		assert.Equal(t, "/home/file1", calls[0].Path)
+		assert.Equal(t, "eio", calls[0].Args["rule"])
+		assert.Equal(t, "Stat", calls[0].Args["op"])
+		assert.Equal(t, syscall.EIO, calls[0].Err)
+	}
+	assert.Equal(t, 2, fs.CallCount("Stat", "/home/file1"))
+}
+
+func TestRule_firesConcurrently(t *testing.T) {
+	r := NewRule("eio").Every(2).Probability(0.999, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.fires()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(800), r.count)
+	assert.InDelta(t, 400, r.Fired(), 5)
+}
+
+func TestRule_toggledConcurrently(t *testing.T) {
+	r := NewRule("garbage").Corrupt(Garbage(16))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i == 0 {
+					r.Disable()
+					r.Enable()
+					continue
+				}
+				r.matches("ReadFile", "/home/file1")
+				assert.Len(t, r.transform([]byte("file1")), 21)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, r.Enabled())
+}
@@ -0,0 +1,87 @@
+package file
+
+import (
+	"errors"
+)
+
+// Exhausted determines the behaviour of a sequence after its last step.
+type Exhausted int
+
+const (
+	// RepeatLast repeats the last step (default).
+	RepeatLast Exhausted = iota
+	// Cycle starts again with the first step.
+	Cycle
+	// Fail returns ErrSequenceExhausted.
+	Fail
+)
+
+// ErrSequenceExhausted is returned by a sequence with mode Fail after its last
+// step.
+var ErrSequenceExhausted = errors.New("fsmocker: sequence exhausted")
+
+// Step is a step of a sequence. A step returns either data or an error.
+type Step struct {
+	Data []byte
+	Err  error
+}
+
+// Sequence holds responses returned on successive reads of a file (ReadFile
+// and Open).
+type Sequence struct {
+	Steps     []Step
+	Exhausted Exhausted
+	next      int
+}
+
+// Next returns the next step of the sequence.
+func (s *Sequence) Next() Step {
+	if len(s.Steps) == 0 {
+		return Step{Err: ErrSequenceExhausted}
+	}
+	i := s.next
+	if i >= len(s.Steps) {
+		switch s.Exhausted {
+		case Cycle:
+			i = i % len(s.Steps)
+		case Fail:
+			return Step{Err: ErrSequenceExhausted}
+		default:
+			i = len(s.Steps) - 1
+		}
+	}
+	s.next++
+	return s.Steps[i]
+}
+
+// Reset starts the sequence again with the first step.
+func (s *Sequence) Reset() {
+	s.next = 0
+}
+
+func (s *setter) sequence() *Sequence {
+	if s.fi.Sequence == nil {
+		s.fi.Sequence = &Sequence{}
+	}
+	return s.fi.Sequence
+}
+
+// Then appends a step returning data to the sequence of the file.
+func (s *setter) Then(data []byte) Configer {
+	seq := s.sequence()
+	seq.Steps = append(seq.Steps, Step{Data: data})
+	return s
+}
+
+// ThenError appends a step returning err to the sequence of the file.
+func (s *setter) ThenError(err error) Configer {
+	seq := s.sequence()
+	seq.Steps = append(seq.Steps, Step{Err: err})
+	return s
+}
+
+// OnExhausted sets the behaviour after the last step of the sequence.
+func (s *setter) OnExhausted(v Exhausted) Configer {
+	s.sequence().Exhausted = v
+	return s
+}
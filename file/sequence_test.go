@@ -0,0 +1,76 @@
+package file
+
+import (
+	"io/ioutil"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Sequence(t *testing.T) {
+	type result struct {
+		data string
+		err  error
+	}
+	tests := []struct {
+		name      string
+		exhausted Exhausted
+		want      []result
+	}{
+		{
+			name:      "repeatLast",
+			exhausted: RepeatLast,
+			want:      []result{{data: "v1"}, {err: syscall.EIO}, {data: "v2"}, {data: "v2"}, {data: "v2"}},
+		},
+		{
+			name:      "cycle",
+			exhausted: Cycle,
+			want:      []result{{data: "v1"}, {err: syscall.EIO}, {data: "v2"}, {data: "v1"}, {err: syscall.EIO}},
+		},
+		{
+			name:      "fail",
+			exhausted: Fail,
+			want:      []result{{data: "v1"}, {err: syscall.EIO}, {data: "v2"}, {err: ErrSequenceExhausted}, {err: ErrSequenceExhausted}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.Config("/home/file1").Then([]byte("v1")).ThenError(syscall.EIO).Then([]byte("v2")).OnExhausted(tt.exhausted)
+			got := []result{}
+			for range tt.want {
+				data, err := fs.ReadFile("/home/file1")
+				got = append(got, result{data: string(data), err: err})
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, []byte("file1"), fs.Config("/home/file1").Data(), "stored data must not change")
+		})
+	}
+}
+
+func TestFS_Sequence_open(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	fs.Config("/home/file1").Then([]byte("v1")).ThenError(syscall.EIO).Then([]byte("v2"))
+
+	f, err := fs.Open("/home/file1")
+	assert.NoError(t, err)
+	data, _ := ioutil.ReadAll(f)
+	assert.Equal(t, []byte("v1"), data)
+
+	_, err = fs.Open("/home/file1")
+	assert.Error(t, err)
+
+	f, err = fs.Open("/home/file1")
+	assert.NoError(t, err)
+	fi, _ := f.Stat()
+	assert.Equal(t, int64(2), fi.Size())
+}
+
+func TestSequence_Reset(t *testing.T) {
+	seq := &Sequence{Steps: []Step{{Data: []byte("a")}, {Data: []byte("b")}}}
+	assert.Equal(t, []byte("a"), seq.Next().Data)
+	seq.Reset()
+	assert.Equal(t, []byte("a"), seq.Next().Data)
+	assert.Equal(t, ErrSequenceExhausted, (&Sequence{}).Next().Err)
+}
@@ -0,0 +1,42 @@
+package file
+
+import (
+	"testing"
+)
+
+// WithStrict is an option to enable the strict mode. In strict mode every
+// access to a path which neither was declared nor created fails the test,
+// unless the path matches one of the allow patterns (see MatchPattern).
+func WithStrict(t testing.TB, allow ...string) Option {
+	return func(fs *FS) {
+		fs.SetStrict(t, allow...)
+	}
+}
+
+// SetStrict enables the strict mode (see WithStrict). A nil t disables it.
+func (fs *FS) SetStrict(t testing.TB, allow ...string) {
+	fs.strict = t
+	fs.strictAllow = allow
+}
+
+// missing is called whenever path is accessed but does not exist.
+func (fs *FS) missing(path string, op string) {
+	if fs.strict == nil || fs.removed[path] {
+		return
+	}
+	for _, pattern := range fs.strictAllow {
+		if MatchPattern(pattern, path) {
+			return
+		}
+	}
+	fs.strict.Errorf("fsmocker: strict mode: %s(%s): path was not declared", op, path)
+}
+
+// markRemoved records that path was removed. It was declared or created
+// before, so strict mode does not report it.
+func (fs *FS) markRemoved(path string) {
+	if fs.removed == nil {
+		fs.removed = map[string]bool{}
+	}
+	fs.removed[path] = true
+}
@@ -0,0 +1,95 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/shebang-go/fsmocker/internal/faket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_strict(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      []string
+		op         func(fs *FS)
+		wantErrors []string
+	}{
+		{
+			name: "declared",
+			op:   func(fs *FS) { fs.ReadFile("/home/file1") },
+		},
+		{
+			name: "created",
+			op: func(fs *FS) {
+				fs.WriteFile("/home/new", nil, 0644)
+				fs.Stat("/home/new")
+			},
+		},
+		{
+			name: "createdRemoved",
+			op: func(fs *FS) {
+				fs.WriteFile("/home/new", nil, 0644)
+				fs.Remove("/home/new")
+				fs.Stat("/home/new")
+			},
+		},
+		{
+			name: "renamedAway",
+			op: func(fs *FS) {
+				fs.Mkdir("/home/new", 0755)
+				fs.WriteFile("/home/new/f", nil, 0644)
+				fs.Rename("/home/new", "/home/old")
+				fs.Stat("/home/new/f")
+				fs.RemoveAll("/home/old")
+				fs.Stat("/home/old/f")
+			},
+		},
+		{
+			name: "declaredRemoved",
+			op: func(fs *FS) {
+				fs.Remove("/home/file1")
+				fs.Stat("/home/file1")
+			},
+		},
+		{
+			name:       "undeclared",
+			op:         func(fs *FS) { fs.Stat("/home/missing") },
+			wantErrors: []string{"fsmocker: strict mode: Stat(/home/missing): path was not declared"},
+		},
+		{
+			name:       "undeclaredParent",
+			op:         func(fs *FS) { fs.WriteFile("/missing/new", nil, 0644) },
+			wantErrors: []string{"fsmocker: strict mode: WriteFile(/missing): path was not declared"},
+		},
+		{
+			name:       "undeclaredDir",
+			op:         func(fs *FS) { fs.ReadDir("/missing") },
+			wantErrors: []string{"fsmocker: strict mode: ReadDir(/missing): path was not declared"},
+		},
+		{
+			name:  "allowed",
+			allow: []string{"/home/*.lock", "/etc/**"},
+			op: func(fs *FS) {
+				fs.Stat("/home/app.lock")
+				fs.ReadFile("/etc/app/config.yaml")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &faket.T{}
+			fs := newFS(t, homeStubs())
+			fs.SetStrict(ft, tt.allow...)
+			tt.op(fs)
+			assert.Equal(t, tt.wantErrors, ft.Errors())
+		})
+	}
+}
+
+func TestFS_strict_disabled(t *testing.T) {
+	ft := &faket.T{}
+	fs := newFS(t, nil, WithStrict(ft))
+	fs.SetStrict(nil)
+	fs.Stat("/missing")
+	assert.Empty(t, ft.Errors())
+}
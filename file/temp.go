@@ -0,0 +1,180 @@
+package file
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// DefaultTempDir is the temp directory of a FS unless changed with
+// WithTempDir.
+const DefaultTempDir = "/tmp"
+
+var errPatternHasSeparator = errors.New("pattern contains path separator")
+
+// WithTempDir is an option to set the directory returned by TempDir.
+func WithTempDir(dir string) Option {
+	return func(fs *FS) {
+		fs.SetTempDir(dir)
+	}
+}
+
+// WithTempSeed is an option to seed the generator of temp file names.
+func WithTempSeed(seed int64) Option {
+	return func(fs *FS) {
+		fs.SetTempSeed(seed)
+	}
+}
+
+// SetTempDir sets the directory returned by TempDir.
+func (fs *FS) SetTempDir(dir string) {
+	fs.tempDir = dir
+}
+
+// SetTempSeed seeds the generator of temp file names. Stubs using the same
+// seed create the same sequence of names.
+func (fs *FS) SetTempSeed(seed int64) {
+	fs.tempRand = rand.New(rand.NewSource(seed))
+}
+
+// TempDir is a stub for os.TempDir
+func (fs *FS) TempDir() string {
+	if fs.tempDir == "" {
+		return DefaultTempDir
+	}
+	return fs.tempDir
+}
+
+func (fs *FS) nextRandom() string {
+	if fs.tempRand == nil {
+		fs.SetTempSeed(0)
+	}
+	return strconv.FormatUint(uint64(fs.tempRand.Uint32()), 10)
+}
+
+// tempRoot returns dir or the temp directory, which is created on demand
+// (there is always a temp directory on a real system).
+func (fs *FS) tempRoot(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	dir = fs.TempDir()
+	if _, ok := fs.PathStubs[fs.resolve(dir)]; !ok {
+		fs.mkdirAll(fs.resolve(dir), 0777|os.ModeSticky)
+	}
+	return dir
+}
+
+func prefixAndSuffix(pattern string) (prefix, suffix string, err error) {
+	if strings.ContainsRune(pattern, os.PathSeparator) {
+		return "", "", errPatternHasSeparator
+	}
+	if pos := strings.LastIndexByte(pattern, '*'); pos != -1 {
+		prefix, suffix = pattern[:pos], pattern[pos+1:]
+	} else {
+		prefix = pattern
+	}
+	return prefix, suffix, nil
+}
+
+func joinPath(dir, name string) string {
+	if len(dir) > 0 && os.IsPathSeparator(dir[len(dir)-1]) {
+		return dir + name
+	}
+	return dir + string(os.PathSeparator) + name
+}
+
+// CreateTemp is a stub for os.CreateTemp
+// The operation is recorded with the path of the created file.
+func (fs *FS) CreateTemp(dir, pattern string) (*File, error) {
+	root := fs.tempRoot(dir)
+	req := &Request{Op: "CreateTemp", Path: fs.resolve(root), Args: testdouble.Args{"dir": dir, "pattern": pattern}}
+	resp := fs.run(req, func(oc *OpContext) {
+		f, err := fs.createTemp(root, pattern)
+		if err != nil {
+			oc.Err = err
+			return
+		}
+		oc.Path = f.path
+		oc.Result = f
+	})
+	f, _ := resp.Result.(*File)
+	return f, pathError("createtemp", pattern, resp.Err)
+}
+
+func (fs *FS) createTemp(dir, pattern string) (*File, error) {
+	prefix, suffix, err := prefixAndSuffix(pattern)
+	if err != nil {
+		return nil, &os.PathError{Op: "createtemp", Path: pattern, Err: err}
+	}
+	prefix = joinPath(dir, prefix)
+
+	try := 0
+	for {
+		name := prefix + fs.nextRandom() + suffix
+		path := fs.resolve(name)
+		fi, err := fs.openFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			if try++; try < 10000 {
+				continue
+			}
+			return nil, &os.PathError{Op: "createtemp", Path: prefix + "*" + suffix, Err: os.ErrExist}
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &File{fs: fs, fi: fi, name: name, path: path, flag: os.O_RDWR}, nil
+	}
+}
+
+// MkdirTemp is a stub for os.MkdirTemp
+// The operation is recorded with the path of the created directory.
+func (fs *FS) MkdirTemp(dir, pattern string) (string, error) {
+	root := fs.tempRoot(dir)
+	req := &Request{Op: "MkdirTemp", Path: fs.resolve(root), Args: testdouble.Args{"dir": dir, "pattern": pattern}}
+	resp := fs.run(req, func(oc *OpContext) {
+		name, err := fs.mkdirTemp(root, pattern)
+		if err != nil {
+			oc.Err = err
+			return
+		}
+		oc.Path = fs.resolve(name)
+		oc.Result = name
+	})
+	name, _ := resp.Result.(string)
+	return name, pathError("mkdirtemp", pattern, resp.Err)
+}
+
+func (fs *FS) mkdirTemp(dir, pattern string) (string, error) {
+	prefix, suffix, err := prefixAndSuffix(pattern)
+	if err != nil {
+		return "", &os.PathError{Op: "mkdirtemp", Path: pattern, Err: err}
+	}
+	prefix = joinPath(dir, prefix)
+
+	try := 0
+	for {
+		name := prefix + fs.nextRandom() + suffix
+		err := fs.mkdir(fs.resolve(name), 0700, "MkdirTemp")
+		if err == nil {
+			return name, nil
+		}
+		err = &os.PathError{Op: "mkdir", Path: name, Err: err}
+		if os.IsExist(err) {
+			if try++; try < 10000 {
+				continue
+			}
+			return "", &os.PathError{Op: "mkdirtemp", Path: dir + string(os.PathSeparator) + prefix + "*" + suffix, Err: os.ErrExist}
+		}
+		if os.IsNotExist(err) {
+			if _, err := fs.getFile(fs.resolve(dir), "MkdirTemp"); os.IsNotExist(err) {
+				return "", &os.PathError{Op: "stat", Path: dir, Err: err}
+			}
+		}
+		return "", err
+	}
+}
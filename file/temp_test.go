@@ -0,0 +1,77 @@
+package file
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempName(seed int64, n int) string {
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < n; i++ {
+		r.Uint32()
+	}
+	return strconv.FormatUint(uint64(r.Uint32()), 10)
+}
+
+func TestFS_TempDir(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	assert.Equal(t, DefaultTempDir, fs.TempDir())
+	fs.SetTempDir("/scratch")
+	assert.Equal(t, "/scratch", fs.TempDir())
+}
+
+func TestFS_CreateTemp(t *testing.T) {
+	tests := []struct {
+		name     string
+		dir      string
+		pattern  string
+		want     string
+		wantErr  error
+		existing []string
+	}{
+		{name: "defaultDir", pattern: "app", want: "/tmp/app" + tempName(42, 0)},
+		{name: "star", dir: "/home", pattern: "app-*.log", want: "/home/app-" + tempName(42, 0) + ".log"},
+		{name: "lastStar", dir: "/home", pattern: "a*b*c", want: "/home/a*b" + tempName(42, 0) + "c"},
+		{name: "trailingSlash", dir: "/home/", pattern: "*", want: "/home/" + tempName(42, 0)},
+		{name: "retryOnExist", dir: "/home", pattern: "x", want: "/home/x" + tempName(42, 1), existing: []string{"/home/x" + tempName(42, 0)}},
+		{name: "errorSeparator", dir: "/home", pattern: "a/b", wantErr: errPatternHasSeparator},
+		{name: "errorNoDir", dir: "/invalid", pattern: "x", wantErr: os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.SetTempSeed(42)
+			for _, p := range tt.existing {
+				assert.NoError(t, fs.WriteFile(p, nil, 0644))
+			}
+			f, err := fs.CreateTemp(tt.dir, tt.pattern)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, f.Name())
+			fi, err := fs.Stat(tt.want)
+			assert.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), fi.Mode())
+		})
+	}
+}
+
+func TestFS_MkdirTemp(t *testing.T) {
+	fs := newFS(t, nil, WithTempDir("/var/tmp"), WithTempSeed(1))
+	dir, err := fs.MkdirTemp("", "build-*")
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/tmp/build-"+tempName(1, 0), dir)
+	fi, err := fs.Stat(dir)
+	assert.NoError(t, err)
+	assert.True(t, fi.IsDir())
+
+	_, err = fs.MkdirTemp("/invalid", "x")
+	assert.True(t, errors.Is(err, os.ErrNotExist), "got %v", err)
+}
@@ -0,0 +1,47 @@
+package file
+
+// Transient lets operations on a file fail for the first attempts (see
+// Configer.FailFirst).
+type Transient struct {
+	// Err is returned for the first N attempts of an operation
+	Err error
+	N   int
+	// Ops are the affected operations (ex: ReadFile, File.Read), all
+	// operations if empty
+	Ops      []string
+	attempts map[string]int
+}
+
+// Attempts returns the number of attempts of op.
+func (t *Transient) Attempts(op string) int {
+	return t.attempts[op]
+}
+
+// Reset resets the attempt counters, so the next attempts fail again.
+func (t *Transient) Reset() {
+	t.attempts = nil
+}
+
+// attempt counts an attempt of op and returns Err for the first N attempts.
+func (t *Transient) attempt(op string) error {
+	if len(t.Ops) > 0 && !contains(t.Ops, op) {
+		return nil
+	}
+	if t.attempts == nil {
+		t.attempts = make(map[string]int)
+	}
+	t.attempts[op]++
+	if t.attempts[op] <= t.N {
+		return t.Err
+	}
+	return nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
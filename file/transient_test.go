@@ -0,0 +1,71 @@
+package file
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Transient(t *testing.T) {
+	tests := []struct {
+		name     string
+		ops      []string
+		op       func(fs *FS) error
+		attempts int
+		wantErrs []error
+	}{
+		{
+			name:     "readFile",
+			op:       func(fs *FS) error { _, err := fs.ReadFile("/home/file1"); return err },
+			attempts: 4,
+			wantErrs: []error{syscall.EINTR, syscall.EINTR, nil, nil},
+		},
+		{
+			name:     "open",
+			ops:      []string{"Open"},
+			op:       func(fs *FS) error { _, err := fs.Open("/home/file1"); return err },
+			attempts: 3,
+			wantErrs: []error{syscall.EINTR, syscall.EINTR, nil},
+		},
+		{
+			name:     "otherOp",
+			ops:      []string{"Open"},
+			op:       func(fs *FS) error { _, err := fs.Stat("/home/file1"); return err },
+			attempts: 1,
+			wantErrs: []error{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			fs.Config("/home/file1").FailFirst(2, syscall.EINTR, tt.ops...)
+			got := []error{}
+			for i := 0; i < tt.attempts; i++ {
+				err := tt.op(fs)
+				var errno syscall.Errno
+				if errors.As(err, &errno) {
+					err = errno
+				}
+				got = append(got, err)
+			}
+			assert.Equal(t, tt.wantErrs, got)
+		})
+	}
+}
+
+func TestFS_TransientAttempts(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	c := fs.Config("/home/file1").FailFirst(1, syscall.ESTALE, "ReadFile")
+	for i := 0; i < 3; i++ {
+		fs.ReadFile("/home/file1")
+	}
+	assert.Equal(t, 3, c.Attempts("ReadFile"))
+	assert.Equal(t, 0, c.Attempts("Stat"))
+
+	c.ResetAttempts()
+	assert.Equal(t, 0, c.Attempts("ReadFile"))
+	_, err := fs.ReadFile("/home/file1")
+	assert.Equal(t, syscall.ESTALE, err)
+}
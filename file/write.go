@@ -0,0 +1,249 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// requireParent checks that the parent directory of path exists.
+func (fs *FS) requireParent(path string, op string) (*FileInfo, error) {
+	dir := filepath.Dir(path)
+	v, ok := fs.PathStubs[dir]
+	if !ok {
+		fs.missing(dir, op)
+		if err := fs.notExist(dir); err != os.ErrNotExist {
+			return nil, err
+		}
+		return nil, syscall.ENOENT
+	}
+	if v.Error != nil {
+		return nil, v.Error
+	}
+	if !v.IsDir() {
+		return nil, syscall.ENOTDIR
+	}
+	fs.access(dir)
+	return v, nil
+}
+
+// createFile returns the file for path and creates it if it does not exist.
+func (fs *FS) createFile(path string, perm os.FileMode, op string) (*FileInfo, error) {
+	if v, ok := fs.PathStubs[path]; ok {
+		if v.Error != nil {
+			return nil, v.Error
+		}
+		if v.IsDir() {
+			return nil, syscall.EISDIR
+		}
+		return v, nil
+	}
+	parent, err := fs.requireParent(path, op)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.allocInode(); err != nil {
+		return nil, err
+	}
+	now := fs.now()
+	fi := &FileInfo{
+		FName:    filepath.Base(path),
+		FMode:    perm & os.ModePerm,
+		FModTime: now,
+		FCTime:   now,
+		FATime:   now,
+		Path:     path,
+	}
+	fs.PathStubs[path] = fi
+	fs.charge(0, 1)
+	fs.touch(parent)
+	return fi, nil
+}
+
+// WriteFile is a stub for ioutil.WriteFile
+func (fs *FS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	req := &Request{Op: "WriteFile", Path: fs.resolve(filename), Args: testdouble.Args{"perm": perm, "size": len(data)}}
+	resp := fs.run(req, func(oc *OpContext) {
+		err := fs.checkCreate(filename, oc.Path, "WriteFile")
+		var fi *FileInfo
+		if err == nil {
+			fi, err = fs.createFile(oc.Path, perm, "WriteFile")
+		}
+		if err != nil {
+			oc.Err = pathError("open", filename, err)
+			return
+		}
+		data := applyTransform(oc.Transform, data)
+		n, err := fs.allocBytes(fi, int64(len(data)))
+		old := sizeOf(fi)
+		fi.Data = append([]byte{}, data[:n]...)
+		fi.FSize = int64(len(fi.Data))
+		fs.resized(fi, old)
+		fs.touch(fi)
+		oc.Err = pathError("write", filename, err)
+	})
+	return pathError("open", filename, resp.Err)
+}
+
+// Truncate is a stub for os.Truncate
+func (fs *FS) Truncate(name string, size int64) error {
+	req := &Request{Op: "Truncate", Path: fs.resolve(name), Args: testdouble.Args{"size": size}}
+	resp := fs.run(req, func(oc *OpContext) {
+		fi, err := fs.lookup(name, oc.Path, "Truncate")
+		if err == nil && fi.IsDir() {
+			err = syscall.EISDIR
+		}
+		if err == nil && size < 0 {
+			err = syscall.EINVAL
+		}
+		if err == nil && size > sizeOf(fi) {
+			_, err = fs.allocBytes(fi, size)
+		}
+		if err != nil {
+			oc.Err = pathError("truncate", name, err)
+			return
+		}
+		old := sizeOf(fi)
+		data := make([]byte, size)
+		copy(data, fi.Data)
+		fi.Data = data
+		fi.FSize = size
+		fs.resized(fi, old)
+		fs.touch(fi)
+	})
+	return pathError("truncate", name, resp.Err)
+}
+
+// Chmod is a stub for os.Chmod
+func (fs *FS) Chmod(name string, mode os.FileMode) error {
+	req := &Request{Op: "Chmod", Path: fs.resolve(name), Args: testdouble.Args{"mode": mode}}
+	resp := fs.run(req, func(oc *OpContext) {
+		fi, err := fs.lookup(name, oc.Path, "Chmod")
+		if err != nil {
+			oc.Err = err
+			return
+		}
+		fi.FMode = fi.FMode&^os.ModePerm | mode&os.ModePerm
+		fi.FCTime = fs.now()
+	})
+	return pathError("chmod", name, resp.Err)
+}
+
+// Rename is a stub for os.Rename
+func (fs *FS) Rename(oldpath, newpath string) error {
+	from, to := fs.resolve(oldpath), fs.resolve(newpath)
+	req := &Request{Op: "Rename", Path: from, Args: testdouble.Args{"newpath": to}}
+	resp := fs.run(req, func(oc *OpContext) {
+		oc.Err = fs.renameNames(oldpath, newpath, oc.Path, to)
+	})
+	switch resp.Err.(type) {
+	case nil, *os.LinkError:
+		return resp.Err
+	}
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: resp.Err}
+}
+
+// renameNames is rename for oldpath and newpath as written (see checkName).
+// from and to are the resolved paths.
+func (fs *FS) renameNames(oldpath, newpath, from, to string) error {
+	// like rename(2), the parents of oldpath are looked up first
+	if err := fs.checkName(oldpath, false); err != nil {
+		return err
+	}
+	if _, err := fs.requireParent(from, "Rename"); err != nil {
+		return err
+	}
+	if err := fs.checkName(newpath, false); err != nil {
+		return err
+	}
+	if _, err := fs.requireParent(to, "Rename"); err != nil {
+		return err
+	}
+	// like os.Rename, a directory renamed to itself under another name
+	// reaches rename(2)
+	same := fs.absName(oldpath) == fs.absName(newpath)
+	if dst, ok := fs.PathStubs[to]; ok && dst.IsDir() && (from != to || same) {
+		if err := fs.checkName(oldpath, true); err != nil {
+			return err
+		}
+		return fs.rename(from, to)
+	}
+	for _, name := range []string{oldpath, newpath} {
+		if base := filepath.Base(name); base == "." || base == ".." {
+			return syscall.EBUSY
+		}
+	}
+	sep := string(os.PathSeparator)
+	if src, ok := fs.PathStubs[from]; ok && src.Error == nil && !src.IsDir() &&
+		(strings.HasSuffix(oldpath, sep) || strings.HasSuffix(newpath, sep)) {
+		return syscall.ENOTDIR
+	}
+	if from == to {
+		_, err := fs.getFile(from, "Rename")
+		return err
+	}
+	return fs.rename(from, to)
+}
+
+func (fs *FS) rename(from string, to string) error {
+	// like os.Rename, renaming to an existing directory fails
+	if dst, ok := fs.PathStubs[to]; ok && dst.Error == nil && dst.IsDir() {
+		if _, err := fs.getFile(from, "Rename"); err != nil {
+			return err
+		}
+		return syscall.EEXIST
+	}
+	if _, err := fs.requireParent(from, "Rename"); err != nil {
+		return err
+	}
+	newParent, err := fs.requireParent(to, "Rename")
+	if err != nil {
+		return err
+	}
+	src, err := fs.getFile(from, "Rename")
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	if src.IsDir() && isWithin(to, from) {
+		return syscall.EINVAL
+	}
+	if dst, ok := fs.PathStubs[to]; ok {
+		switch {
+		case dst.Error != nil:
+			return dst.Error
+		case src.IsDir() && !dst.IsDir():
+			return syscall.ENOTDIR
+		}
+		fs.deleteStub(to)
+	}
+	oldParent := fs.PathStubs[filepath.Dir(from)]
+	children := make(map[string]*FileInfo)
+	for k, v := range fs.PathStubs {
+		if k != from && isWithin(k, from) {
+			children[k] = v
+			delete(fs.PathStubs, k)
+			fs.markRemoved(k)
+		}
+	}
+	for k, v := range children {
+		v.Path = filepath.Join(to, k[len(from):])
+		fs.PathStubs[v.Path] = v
+	}
+	delete(fs.PathStubs, from)
+	fs.markRemoved(from)
+	src.FName = filepath.Base(to)
+	src.Path = to
+	src.FCTime = fs.now()
+	fs.PathStubs[to] = src
+	if oldParent != nil {
+		fs.touch(oldParent)
+	}
+	fs.touch(newParent)
+	return nil
+}
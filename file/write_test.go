@@ -0,0 +1,112 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_WriteFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		wantErr  error
+	}{
+		{name: "create", filename: "/home/new"},
+		{name: "overwrite", filename: "/home/file1"},
+		{name: "relative", filename: "home/dir/../new"},
+		{name: "errorNoParent", filename: "/invalid/new", wantErr: os.ErrNotExist},
+		{name: "errorParentIsFile", filename: "/home/file1/new", wantErr: syscall.ENOTDIR},
+		{name: "errorIsDir", filename: "/home/dir", wantErr: syscall.EISDIR},
+		{name: "errorPreConfigured", filename: "/home/bad", wantErr: errBad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			err := fs.WriteFile(tt.filename, []byte("data"), 0600)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			data, err := fs.ReadFile(tt.filename)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("data"), data)
+			fi, _ := fs.Stat(tt.filename)
+			assert.Equal(t, int64(4), fi.Size())
+		})
+	}
+}
+
+func TestFS_Truncate(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	assert.NoError(t, fs.Truncate("/home/file1", 2))
+	data, _ := fs.ReadFile("/home/file1")
+	assert.Equal(t, []byte("fi"), data)
+
+	assert.NoError(t, fs.Truncate("/home/file1", 4))
+	data, _ = fs.ReadFile("/home/file1")
+	assert.Equal(t, []byte("fi\x00\x00"), data)
+
+	assert.True(t, errors.Is(fs.Truncate("/home/dir", 0), syscall.EISDIR))
+	assert.True(t, errors.Is(fs.Truncate("/home/invalid", 0), os.ErrNotExist))
+}
+
+func TestFS_Chmod(t *testing.T) {
+	fs := newFS(t, homeStubs())
+	assert.NoError(t, fs.Chmod("/home/file1", 0600|os.ModeSetuid))
+	assert.Equal(t, os.FileMode(0600), fs.FileInfo("/home/file1").Mode())
+	assert.True(t, errors.Is(fs.Chmod("/home/invalid", 0600), os.ErrNotExist))
+}
+
+func TestFS_Rename(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		wantPaths []string
+		wantGone  []string
+		wantErr   error
+	}{
+		{name: "file", from: "/home/file1", to: "/home/file3", wantPaths: []string{"/home/file3"}, wantGone: []string{"/home/file1"}},
+		{name: "fileReplace", from: "/home/file1", to: "/home/dir/file2", wantPaths: []string{"/home/dir/file2"}, wantGone: []string{"/home/file1"}},
+		{name: "dir", from: "/home/dir", to: "/home/moved", wantPaths: []string{"/home/moved", "/home/moved/file2"}, wantGone: []string{"/home/dir", "/home/dir/file2"}},
+		{name: "same", from: "/home/file1", to: "/home/file1", wantPaths: []string{"/home/file1"}},
+		{name: "errorNotExist", from: "/home/invalid", to: "/home/file3", wantErr: os.ErrNotExist},
+		{name: "errorNoParent", from: "/home/file1", to: "/invalid/file3", wantErr: os.ErrNotExist},
+		{name: "errorFileToDir", from: "/home/file1", to: "/home/empty", wantErr: syscall.EEXIST},
+		{name: "errorDirToEmptyDir", from: "/home/dir", to: "/home/empty", wantErr: syscall.EEXIST},
+		{name: "errorDirToFile", from: "/home/dir", to: "/home/file1", wantErr: syscall.ENOTDIR},
+		{name: "errorDirToDir", from: "/home/empty", to: "/home/dir", wantErr: syscall.EEXIST},
+		{name: "errorDirToFileParent", from: "/home/dir", to: "/home/file1/dir", wantErr: syscall.ENOTDIR},
+		{name: "errorIntoItself", from: "/home/dir", to: "/home/dir/sub", wantErr: syscall.EINVAL},
+		{name: "dirOtherName", from: "/home/dir/", to: "/home/dir", wantPaths: []string{"/home/dir"}},
+		{name: "errorDotDot", from: "/home/dir/..", to: "/home/moved", wantErr: syscall.EBUSY},
+		{name: "errorTrailingFile", from: "/home/file1/", to: "/home/file3", wantErr: syscall.ENOTDIR},
+		{name: "errorDotDotNoParent", from: "/home/file1", to: "/home/invalid/../file3", wantErr: os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFS(t, homeStubs())
+			err := fs.Rename(tt.from, tt.to)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, p := range tt.wantPaths {
+				fi, err := fs.Stat(p)
+				if assert.NoError(t, err, p) {
+					assert.Equal(t, p, fi.(*FileInfo).Path)
+				}
+			}
+			for _, p := range tt.wantGone {
+				_, err := fs.Stat(p)
+				assert.True(t, errors.Is(err, os.ErrNotExist), p)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@
 package fsmocker
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,6 +12,10 @@ import (
 	"github.com/shebang-go/fsmocker/testdouble"
 )
 
+// File is an open file returned by the Open methods of a Stub (see
+// file.Handle).
+type File = file.Handle
+
 type Stub interface {
 	Config(p string) file.Configer
 	Options(opts ...stub.Option)
@@ -20,8 +23,24 @@ type Stub interface {
 	ReadFile(path string) ([]byte, error)
 	ReadDir(path string) ([]os.FileInfo, error)
 	Walk(root string, walkFn filepath.WalkFunc) error
+	Glob(pattern string) ([]string, error)
 	WriteFile(filename string, data []byte, perm os.FileMode) error
+	Truncate(name string, size int64) error
+	Chmod(name string, mode os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Open(name string) (File, error)
+	Create(name string) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Mkdir(name string, perm os.FileMode) error
+	MkdirAll(name string, perm os.FileMode) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	TempDir() string
+	CreateTemp(dir, pattern string) (File, error)
+	MkdirTemp(dir, pattern string) (string, error)
 	Abs(p string) (string, error)
+	Chdir(dir string) error
+	Getwd() (string, error)
 	FileInfo(p string) os.FileInfo
 }
 type TestDoubleOption func(td *testdouble.TestDouble)
@@ -36,17 +55,112 @@ func WithLogging(t *testing.T) TestDoubleOption {
 		td.EnableLogging(t)
 	}
 }
+
+// WithTraceOnFailure is an option to write the calls made to the stub like
+// strace to a file in dir if t failed.
+func WithTraceOnFailure(t testing.TB, dir string) TestDoubleOption {
+	return func(td *testdouble.TestDouble) {
+		testdouble.WithTraceOnFailure(t, dir)(td)
+	}
+}
+
+// WithSink is an option to log to sinks (see testdouble.Sink).
+func WithSink(sinks ...testdouble.Sink) TestDoubleOption {
+	return func(td *testdouble.TestDouble) {
+		td.AddSink(sinks...)
+	}
+}
 func WithGlobalOptions(opts ...TestDoubleOption) StubOption {
 	return func(st *stub.Stub) {
 		for _, opt := range opts {
 			// td := st.(*stub.Stub)
 			td := st.TestDouble()
-			log.Println(">>>", td, opt)
 			opt(td.(*testdouble.TestDouble))
 		}
 	}
 }
 
+// WithT is an option to set the test of the stub. Expectations are verified
+// at the end of the test.
+func WithT(t *testing.T) StubOption {
+	return StubOption(stub.WithT(t))
+}
+
+// WithClock is an option to set the clock used for file timestamps.
+func WithClock(c file.Clock) StubOption {
+	return StubOption(stub.WithClock(c))
+}
+
+// WithTempDir is an option to set the directory returned by TempDir.
+func WithTempDir(dir string) StubOption {
+	return StubOption(stub.WithTempDir(dir))
+}
+
+// WithTempSeed is an option to seed the generator of temp file names.
+func WithTempSeed(seed int64) StubOption {
+	return StubOption(stub.WithTempSeed(seed))
+}
+
+// WithDoublestar is an option to enable ** in Glob patterns.
+func WithDoublestar() StubOption {
+	return StubOption(stub.WithDoublestar())
+}
+
+// WithDirOrder is an option to set the order of entries returned by
+// File.Readdir (ex: file.Shuffle(seed)).
+func WithDirOrder(o file.DirOrder) StubOption {
+	return StubOption(stub.WithDirOrder(o))
+}
+
+// WithStrict is an option to fail the test t on every access to a path which
+// neither was declared nor created. Paths matching one of the allow patterns
+// (ex: /var/run/*.pid, /etc/**) may be missing.
+func WithStrict(t testing.TB, allow ...string) StubOption {
+	return StubOption(stub.WithStrict(t, allow...))
+}
+
+// WithCoverage is an option to report declared paths which were never
+// stat'ed, read, listed or walked at the end of the test t. With
+// file.CoverageFail the test fails if there are unused paths.
+func WithCoverage(t testing.TB, mode file.CoverageMode) StubOption {
+	return StubOption(stub.WithCoverage(t, mode))
+}
+
+// WithDurability is an option to track which changes are durable, so a
+// crash can be simulated (see file.FS.TrackDurability).
+func WithDurability(pageSize int) StubOption {
+	return StubOption(stub.WithDurability(pageSize))
+}
+
+// WithQuota is an option to limit the capacity of the stub (see
+// file.Quota).
+func WithQuota(q file.Quota) StubOption {
+	return StubOption(stub.WithQuota(q))
+}
+
+// OnBefore is an option to register a hook called before each operation op
+// on paths matching pattern (see file.FS.OnBefore).
+func OnBefore(op string, pattern string, fn file.HookFunc) StubOption {
+	return StubOption(stub.OnBefore(op, pattern, fn))
+}
+
+// OnAfter is an option to register a hook called after each operation op on
+// paths matching pattern (see file.FS.OnAfter).
+func OnAfter(op string, pattern string, fn file.HookFunc) StubOption {
+	return StubOption(stub.OnAfter(op, pattern, fn))
+}
+
+// WithInterceptors is an option to add interceptors to the operations of
+// the stub (see file.FS.Use).
+func WithInterceptors(interceptors ...file.Interceptor) StubOption {
+	return StubOption(stub.WithInterceptors(interceptors...))
+}
+
+// WithRules is an option to add fault injection rules (see file.Rule).
+func WithRules(rules ...*file.Rule) StubOption {
+	return StubOption(stub.WithRules(rules...))
+}
+
 func NewStub(paths []string, opts ...StubOption) *stub.Stub {
 	o := []stub.Option{}
 	for _, v := range opts {
@@ -0,0 +1,162 @@
+// Package fsmockertest provides a conformance suite for implementations of
+// fsmocker.Stub, so stubs can be checked against the real file system.
+package fsmockertest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shebang-go/fsmocker"
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Factory returns an empty file system for a scenario. Paths of the file
+// system are absolute below /, the working directory is /.
+type Factory func(t *testing.T) fsmocker.Stub
+
+// Symlinker is implemented by file systems which support symbolic links.
+// The symlink scenarios are not run for other file systems, like the stub
+// which does not support symbolic links.
+type Symlinker interface {
+	Symlink(oldname, newname string) error
+	Readlink(name string) (string, error)
+	Lstat(name string) (os.FileInfo, error)
+}
+
+// scenario is a single conformance test. run returns a summary of the
+// result which is compared with want, errors are summarized by describeErr.
+type scenario struct {
+	name string
+	run  func(fs fsmocker.Stub) (string, error)
+	want string
+}
+
+// RunConformance runs the conformance scenarios as subtests of t, each on a
+// new file system created by factory. The expected results are those of
+// the real file system on Linux with a umask of 022.
+func RunConformance(t *testing.T, factory Factory) {
+	groups := []struct {
+		name      string
+		scenarios []scenario
+	}{
+		{"path", pathScenarios()},
+		{"rename", renameScenarios()},
+		{"file", fileScenarios()},
+		{"glob", globScenarios()},
+		{"symlink", symlinkScenarios()},
+	}
+	for _, g := range groups {
+		t.Run(g.name, func(t *testing.T) {
+			if _, ok := factory(t).(Symlinker); !ok && g.name == "symlink" {
+				t.Skipf("%d scenarios not run: the file system does not implement Symlinker", len(g.scenarios))
+			}
+			for _, s := range g.scenarios {
+				s := s
+				t.Run(s.name, func(t *testing.T) {
+					fs := factory(t)
+					if err := setup(fs); err != nil {
+						t.Fatalf("setup: %v", err)
+					}
+					got, err := s.run(fs)
+					if err != nil {
+						got = describeErr(err)
+					}
+					if got != s.want {
+						t.Errorf("got %q, want %q", got, s.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+// setup creates the tree used by the scenarios:
+//
+//	/d/f      "data"
+//	/d/e/
+//	/d/sub/g  "g"
+//	/h        "h"
+func setup(fs fsmocker.Stub) error {
+	for _, dir := range []string{"/d/e", "/d/sub"} {
+		if err := fs.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	files := []struct{ name, data string }{{"/d/f", "data"}, {"/d/sub/g", "g"}, {"/h", "h"}}
+	for _, f := range files {
+		if err := fs.WriteFile(f.name, []byte(f.data), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeErr summarizes err by its type and errno (ex: PathError ENOENT).
+func describeErr(err error) string {
+	name := testdouble.ErrnoName(testdouble.Errno(err))
+	switch {
+	case err == io.EOF:
+		return "EOF"
+	case errors.Is(err, os.ErrClosed):
+		name = "closed"
+	case errors.Is(err, filepath.ErrBadPattern):
+		return "ErrBadPattern"
+	case name == "":
+		name = err.Error()
+	}
+	switch err.(type) {
+	case *os.PathError:
+		return "PathError " + name
+	case *os.LinkError:
+		return "LinkError " + name
+	}
+	return name
+}
+
+// describe summarizes fi (ex: file 4 -rw-r--r--). Directories are shown
+// without size, it depends on the file system.
+func describe(fi os.FileInfo) string {
+	if fi.IsDir() {
+		return fmt.Sprintf("dir %v", fi.Mode())
+	}
+	return fmt.Sprintf("file %d %v", fi.Size(), fi.Mode())
+}
+
+// names returns the names of entries.
+func names(entries []os.FileInfo) string {
+	s := []string{}
+	for _, e := range entries {
+		s = append(s, e.Name())
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
+
+// state summarizes path after an operation.
+func state(fs fsmocker.Stub, path string) string {
+	fi, err := fs.Stat(path)
+	if err != nil {
+		return describeErr(err)
+	}
+	if fi.IsDir() {
+		return describe(fi)
+	}
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return describeErr(err)
+	}
+	return fmt.Sprintf("%s %q", describe(fi), data)
+}
+
+// then returns the summary of the result of an operation followed by the
+// state of path.
+func then(fs fsmocker.Stub, err error, path string) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return "ok, " + state(fs, path), nil
+}
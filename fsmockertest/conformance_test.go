@@ -0,0 +1,25 @@
+package fsmockertest
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/shebang-go/fsmocker"
+)
+
+func TestConformance_OsFS(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the expected results are those of Linux")
+	}
+	RunConformance(t, func(t *testing.T) fsmocker.Stub {
+		return fsmocker.NewOsFS(t.TempDir())
+	})
+}
+
+// TestConformance_Stub runs the suite without the symlink scenarios, the
+// stub does not support symbolic links.
+func TestConformance_Stub(t *testing.T) {
+	RunConformance(t, func(t *testing.T) fsmocker.Stub {
+		return fsmocker.NewStub(nil)
+	})
+}
@@ -0,0 +1,228 @@
+package fsmockertest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shebang-go/fsmocker"
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Op is an operation of a script (ex: WriteFile /a "xy").
+type Op struct {
+	// Name is the name of the operation (see scriptOps)
+	Name string
+	Path string
+	// NewPath is the destination of Rename
+	NewPath string
+	// Data is written by WriteFile and AppendFile
+	Data string
+	// Size is the size of Truncate
+	Size int64
+	// Mode is the mode of Chmod
+	Mode os.FileMode
+}
+
+func (op Op) String() string {
+	switch op.Name {
+	case "WriteFile", "AppendFile":
+		return fmt.Sprintf("%s %s %q", op.Name, op.Path, op.Data)
+	case "Rename":
+		return fmt.Sprintf("%s %s %s", op.Name, op.Path, op.NewPath)
+	case "Truncate":
+		return fmt.Sprintf("%s %s %d", op.Name, op.Path, op.Size)
+	case "Chmod":
+		return fmt.Sprintf("%s %s %#o", op.Name, op.Path, op.Mode)
+	}
+	return op.Name + " " + op.Path
+}
+
+// Script is a sequence of operations.
+type Script []Op
+
+// String returns the operations of s, one per line.
+func (s Script) String() string {
+	var sb strings.Builder
+	for _, op := range s {
+		sb.WriteString(op.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// scriptOps are the operations a script is decoded into.
+var scriptOps = []string{
+	"Stat", "ReadFile", "ReadDir", "Walk", "WriteFile", "AppendFile", "Mkdir",
+	"MkdirAll", "Remove", "RemoveAll", "Rename", "Truncate", "Chmod", "Chdir",
+}
+
+// scriptPaths are the paths of scripts. They are few, so operations often
+// hit the same files. Some contain .. or end with a separator, which the
+// file system resolves element by element.
+var scriptPaths = []string{"/a", "/b", "/a/b", "/a/c", "/b/a", "/a/b/c", "a", "b/a", "/a/", "a/b/", "/a/b/..", "/a/../b"}
+
+// scriptModes are the modes of Chmod. They are not changed by a umask of
+// 022, so files created by the stub and the real file system match.
+var scriptModes = []os.FileMode{0600, 0644, 0700, 0755}
+
+// maxScript is the number of operations a script is decoded into at most.
+const maxScript = 64
+
+// DecodeScript decodes data (ex: the input of a fuzzer) into a script. Each
+// operation takes three bytes: the operation, the path and an argument
+// (ex: the data to write).
+func DecodeScript(data []byte) Script {
+	s := Script{}
+	for i := 0; i+2 < len(data) && len(s) < maxScript; i += 3 {
+		op := Op{
+			Name: scriptOps[int(data[i])%len(scriptOps)],
+			Path: scriptPaths[int(data[i+1])%len(scriptPaths)],
+		}
+		arg := int(data[i+2])
+		switch op.Name {
+		case "WriteFile", "AppendFile":
+			op.Data = strings.Repeat(string(rune('a'+arg%26)), arg%5)
+		case "Rename":
+			op.NewPath = scriptPaths[arg%len(scriptPaths)]
+		case "Truncate":
+			op.Size = int64(arg % 8)
+		case "Chmod":
+			op.Mode = scriptModes[arg%len(scriptModes)]
+		}
+		s = append(s, op)
+	}
+	return s
+}
+
+// errno summarizes err by its errno (ex: ENOENT).
+func errno(err error) string {
+	if name := testdouble.ErrnoName(testdouble.Errno(err)); name != "" {
+		return name
+	}
+	return err.Error()
+}
+
+// apply runs op on fs and returns a summary of the result.
+func apply(fs fsmocker.Stub, op Op) string {
+	var result string
+	var err error
+	switch op.Name {
+	case "Stat":
+		var fi os.FileInfo
+		if fi, err = fs.Stat(op.Path); err == nil {
+			result = describe(fi)
+		}
+	case "ReadFile":
+		var data []byte
+		data, err = fs.ReadFile(op.Path)
+		result = fmt.Sprintf("%q", data)
+	case "ReadDir":
+		var entries []os.FileInfo
+		entries, err = fs.ReadDir(op.Path)
+		result = names(entries)
+	case "Walk":
+		result, err = walk(fs, op.Path, "")
+	case "WriteFile":
+		err = fs.WriteFile(op.Path, []byte(op.Data), 0644)
+	case "AppendFile":
+		err = appendFile(fs, op.Path, op.Data)
+	case "Mkdir":
+		err = fs.Mkdir(op.Path, 0755)
+	case "MkdirAll":
+		err = fs.MkdirAll(op.Path, 0755)
+	case "Remove":
+		err = fs.Remove(op.Path)
+	case "RemoveAll":
+		err = fs.RemoveAll(op.Path)
+	case "Rename":
+		err = fs.Rename(op.Path, op.NewPath)
+	case "Truncate":
+		err = fs.Truncate(op.Path, op.Size)
+	case "Chmod":
+		err = fs.Chmod(op.Path, op.Mode)
+	case "Chdir":
+		err = fs.Chdir(op.Path)
+	}
+	if err != nil {
+		return errno(err)
+	}
+	return "ok " + result
+}
+
+func appendFile(fs fsmocker.Stub, name string, data string) error {
+	f, err := fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(data)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// tree returns the files below / of fs with their state, one per line.
+func tree(fs fsmocker.Stub) string {
+	var sb strings.Builder
+	err := fs.Walk("/", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// the mode of / depends on the file system
+		if path != "/" {
+			fmt.Fprintf(&sb, "%s %s\n", path, state(fs, path))
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(&sb, "walk: %s\n", errno(err))
+	}
+	return sb.String()
+}
+
+// Diff runs s on file systems created by a and b and returns the first
+// difference of their results, errors (by errno) or final trees. An empty
+// string is returned if there is none.
+func Diff(t *testing.T, a, b Factory, s Script) string {
+	fsA, fsB := a(t), b(t)
+	for i, op := range s {
+		if ra, rb := apply(fsA, op), apply(fsB, op); ra != rb {
+			return fmt.Sprintf("operation %d (%s): %s != %s", i+1, op, ra, rb)
+		}
+	}
+	if ta, tb := tree(fsA), tree(fsB); ta != tb {
+		return fmt.Sprintf("final trees differ:\n%s!=\n%s", ta, tb)
+	}
+	return ""
+}
+
+// Minimize removes operations from s as long as failing returns true and
+// returns the shortest script found.
+func Minimize(s Script, failing func(s Script) bool) Script {
+	for removed := true; removed; {
+		removed = false
+		for i := 0; i < len(s); i++ {
+			shorter := append(append(Script{}, s[:i]...), s[i+1:]...)
+			if failing(shorter) {
+				s = shorter
+				removed = true
+				i--
+			}
+		}
+	}
+	return s
+}
+
+// RunDifferential runs s on file systems created by a and b and fails t if
+// they differ, reporting the difference and the minimized script.
+func RunDifferential(t *testing.T, a, b Factory, s Script) {
+	t.Helper()
+	diff := Diff(t, a, b, s)
+	if diff == "" {
+		return
+	}
+	min := Minimize(s, func(s Script) bool { return Diff(t, a, b, s) != "" })
+	t.Fatalf("%s\nminimized script:\n%s", Diff(t, a, b, min), min)
+}
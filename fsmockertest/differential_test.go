@@ -0,0 +1,118 @@
+package fsmockertest
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/shebang-go/fsmocker"
+	"github.com/stretchr/testify/assert"
+)
+
+func osFactory(t *testing.T) fsmocker.Stub {
+	if runtime.GOOS != "linux" {
+		t.Skip("the stub follows the errors of Linux")
+	}
+	dir, err := ioutil.TempDir("", "fsmocker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return fsmocker.NewOsFS(dir)
+}
+
+func stubFactory(t *testing.T) fsmocker.Stub {
+	return fsmocker.NewStub(nil)
+}
+
+func TestDecodeScript(t *testing.T) {
+	s := DecodeScript([]byte{4, 2, 28, 10, 0, 5, 11, 1, 3, 0xff})
+	assert.Equal(t, "WriteFile /a/b \"ccc\"\nRename /a /a/b/c\nTruncate /b 3\n", s.String())
+	assert.Empty(t, DecodeScript(nil))
+}
+
+func TestMinimize(t *testing.T) {
+	s := Script{{Name: "Stat", Path: "/a"}, {Name: "Mkdir", Path: "/a"}, {Name: "Stat", Path: "/b"}, {Name: "Remove", Path: "/a"}}
+	failing := func(s Script) bool {
+		for _, op := range s {
+			if op.Name == "Remove" {
+				return true
+			}
+		}
+		return false
+	}
+	assert.Equal(t, Script{{Name: "Remove", Path: "/a"}}, Minimize(s, failing))
+}
+
+func TestDiff(t *testing.T) {
+	s := Script{{Name: "WriteFile", Path: "/a", Data: "x"}, {Name: "ReadFile", Path: "/a"}}
+	assert.Equal(t, "", Diff(t, stubFactory, stubFactory, s))
+	withDir := func(t *testing.T) fsmocker.Stub {
+		st := fsmocker.NewStub(nil)
+		assert.NoError(t, st.Mkdir("/a", 0755))
+		return st
+	}
+	assert.Equal(t, `operation 1 (WriteFile /a "x"): ok  != EISDIR`, Diff(t, stubFactory, withDir, s))
+}
+
+// TestDifferential runs random scripts on the stub and the real file
+// system (see FuzzDifferential for more).
+func TestDifferential(t *testing.T) {
+	n := 500
+	if testing.Short() {
+		n = 50
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		data := make([]byte, 3*(1+r.Intn(20)))
+		r.Read(data)
+		RunDifferential(t, osFactory, stubFactory, DecodeScript(data))
+	}
+}
+
+// TestDifferential_known runs scripts for which the stub and the real file
+// system are known to differ. A case failing here is fixed and can go.
+func TestDifferential_known(t *testing.T) {
+	tests := []struct {
+		name   string
+		script Script
+	}{
+		{
+			// a rooted OsFS cleans names leaving /, so os.Rename sees the
+			// same names and fails
+			name:   "renameAboveRoot",
+			script: Script{{Name: "Mkdir", Path: "/a"}, {Name: "Rename", Path: "../a", NewPath: "/a"}},
+		},
+		{
+			// a rooted OsFS joins relative names to the working directory,
+			// so os.MkdirAll creates the removed directory again
+			name: "removedWorkingDir",
+			script: Script{{Name: "MkdirAll", Path: "/a/b"}, {Name: "Chdir", Path: "/a/b"},
+				{Name: "RemoveAll", Path: "/a"}, {Name: "MkdirAll", Path: "../c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotEqual(t, "", Diff(t, osFactory, stubFactory, tt.script))
+		})
+	}
+}
+
+// FuzzDifferential runs scripts decoded from the fuzzer input on the stub and
+// the real file system (see DecodeScript). Run it with:
+//
+//	go test -fuzz=FuzzDifferential ./fsmockertest
+func FuzzDifferential(f *testing.F) {
+	f.Add([]byte{})
+	// MkdirAll /a/b/c, Rename /a /b, Walk /b
+	f.Add([]byte{7, 5, 0, 10, 0, 1, 3, 1, 0})
+	// WriteFile /a "bb", AppendFile /a "cc", Truncate /a 7, ReadFile /a
+	f.Add([]byte{4, 0, 27, 5, 0, 2, 11, 0, 7, 1, 0, 0})
+	// Mkdir /a, Chdir /a, WriteFile a "", Remove /a, RemoveAll /a
+	f.Add([]byte{6, 0, 0, 13, 0, 0, 4, 6, 0, 8, 0, 0, 9, 0, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		RunDifferential(t, osFactory, stubFactory, DecodeScript(data))
+	})
+}
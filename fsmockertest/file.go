@@ -0,0 +1,324 @@
+package fsmockertest
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shebang-go/fsmocker"
+)
+
+// withFile opens name with flag and passes the file to fn. The file is
+// closed afterwards unless fn closed it.
+func withFile(name string, flag int, fn func(f fsmocker.File) (string, error)) func(fs fsmocker.Stub) (string, error) {
+	return func(fs fsmocker.Stub) (string, error) {
+		f, err := fs.OpenFile(name, flag, 0600)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		return fn(f)
+	}
+}
+
+// read returns the result of reading up to n bytes from f.
+func read(f fsmocker.File, n int) (string, error) {
+	buf := make([]byte, n)
+	n, err := f.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%q", buf[:n]), nil
+}
+
+func fileScenarios() []scenario {
+	return []scenario{
+		{
+			name: "Read",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				return read(f, 10)
+			}),
+			want: `"data"`,
+		},
+		{
+			name: "ReadShort",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				return read(f, 3)
+			}),
+			want: `"dat"`,
+		},
+		{
+			name: "ReadEOF",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				if _, err := read(f, 10); err != nil {
+					return "", err
+				}
+				return read(f, 10)
+			}),
+			want: "EOF",
+		},
+		{
+			name: "ReadEmpty",
+			run: withFile("/d/sub/g", os.O_RDWR|os.O_TRUNC, func(f fsmocker.File) (string, error) {
+				return read(f, 10)
+			}),
+			want: "EOF",
+		},
+		{
+			name: "ReadDir",
+			run: withFile("/d", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				return read(f, 10)
+			}),
+			want: "PathError EISDIR",
+		},
+		{
+			name: "ReadWriteOnly",
+			run: withFile("/d/f", os.O_WRONLY, func(f fsmocker.File) (string, error) {
+				return read(f, 10)
+			}),
+			want: "PathError EBADF",
+		},
+		{
+			name: "ReadAfterClose",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				f.Close()
+				return read(f, 10)
+			}),
+			want: "PathError closed",
+		},
+		{
+			name: "WriteReadOnly",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				n, err := f.Write([]byte("x"))
+				return fmt.Sprint(n), err
+			}),
+			want: "PathError EBADF",
+		},
+		{
+			name: "WriteAfterClose",
+			run: withFile("/d/f", os.O_RDWR, func(f fsmocker.File) (string, error) {
+				f.Close()
+				n, err := f.Write([]byte("x"))
+				return fmt.Sprint(n), err
+			}),
+			want: "PathError closed",
+		},
+		{
+			name: "CloseTwice",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				if err := f.Close(); err != nil {
+					return "", err
+				}
+				return "", f.Close()
+			}),
+			want: "PathError closed",
+		},
+		{
+			name: "StatAfterClose",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				f.Close()
+				fi, err := f.Stat()
+				if err != nil {
+					return "", err
+				}
+				return describe(fi), nil
+			}),
+			want: "PathError closed",
+		},
+		{
+			name: "WriteOverwrites",
+			run: func(fs fsmocker.Stub) (string, error) {
+				s, err := withFile("/d/f", os.O_RDWR, func(f fsmocker.File) (string, error) {
+					n, err := f.Write([]byte("DA"))
+					return fmt.Sprint(n), err
+				})(fs)
+				return s + " " + state(fs, "/d/f"), err
+			},
+			want: `2 file 4 -rw-r--r-- "DAta"`,
+		},
+		{
+			name: "ReadThenWrite",
+			run: func(fs fsmocker.Stub) (string, error) {
+				s, err := withFile("/d/f", os.O_RDWR, func(f fsmocker.File) (string, error) {
+					if _, err := read(f, 2); err != nil {
+						return "", err
+					}
+					n, err := f.Write([]byte("TA!"))
+					return fmt.Sprint(n), err
+				})(fs)
+				return s + " " + state(fs, "/d/f"), err
+			},
+			want: `3 file 5 -rw-r--r-- "daTA!"`,
+		},
+		{
+			name: "WriteStat",
+			run: withFile("/d/f", os.O_WRONLY|os.O_APPEND, func(f fsmocker.File) (string, error) {
+				if _, err := f.Write([]byte("++")); err != nil {
+					return "", err
+				}
+				fi, err := f.Stat()
+				if err != nil {
+					return "", err
+				}
+				return describe(fi), nil
+			}),
+			want: "file 6 -rw-r--r--",
+		},
+		{
+			name: "SeekStart",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				if _, err := f.Seek(2, io.SeekStart); err != nil {
+					return "", err
+				}
+				return read(f, 10)
+			}),
+			want: `"ta"`,
+		},
+		{
+			name: "SeekCurrent",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				if _, err := read(f, 1); err != nil {
+					return "", err
+				}
+				off, err := f.Seek(1, io.SeekCurrent)
+				if err != nil {
+					return "", err
+				}
+				s, err := read(f, 10)
+				return fmt.Sprint(off, " ", s), err
+			}),
+			want: `2 "ta"`,
+		},
+		{
+			name: "SeekEnd",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				off, err := f.Seek(-1, io.SeekEnd)
+				if err != nil {
+					return "", err
+				}
+				s, err := read(f, 10)
+				return fmt.Sprint(off, " ", s), err
+			}),
+			want: `3 "a"`,
+		},
+		{
+			name: "SeekNegative",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				off, err := f.Seek(-1, io.SeekStart)
+				return fmt.Sprint(off), err
+			}),
+			want: "PathError EINVAL",
+		},
+		{
+			name: "SeekPastEnd",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				if _, err := f.Seek(10, io.SeekStart); err != nil {
+					return "", err
+				}
+				return read(f, 10)
+			}),
+			want: "EOF",
+		},
+		{
+			name: "WritePastEnd",
+			run: func(fs fsmocker.Stub) (string, error) {
+				_, err := withFile("/d/f", os.O_WRONLY, func(f fsmocker.File) (string, error) {
+					if _, err := f.Seek(6, io.SeekStart); err != nil {
+						return "", err
+					}
+					n, err := f.Write([]byte("!"))
+					return fmt.Sprint(n), err
+				})(fs)
+				return state(fs, "/d/f"), err
+			},
+			want: `file 7 -rw-r--r-- "data\x00\x00!"`,
+		},
+		{
+			name: "Readdir",
+			run: withFile("/d", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				entries, err := f.Readdir(-1)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprint(len(entries)), nil
+			}),
+			want: "3",
+		},
+		{
+			name: "ReaddirFile",
+			run: withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				entries, err := f.Readdir(-1)
+				return fmt.Sprint(len(entries)), err
+			}),
+			want: "PathError ENOTDIR",
+		},
+		{
+			name: "ReaddirEmpty",
+			run: withFile("/d/e", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				entries, err := f.Readdir(1)
+				return fmt.Sprint(len(entries)), err
+			}),
+			want: "EOF",
+		},
+		{
+			name: "OpenDirWrite",
+			run: withFile("/d", os.O_WRONLY, func(f fsmocker.File) (string, error) {
+				return "", nil
+			}),
+			want: "PathError EISDIR",
+		},
+		{
+			name: "CreateDir",
+			run: withFile("/d/e", os.O_RDWR|os.O_CREATE, func(f fsmocker.File) (string, error) {
+				return "", nil
+			}),
+			want: "PathError EISDIR",
+		},
+		{
+			name: "Name",
+			run: withFile("d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+				return f.Name(), nil
+			}),
+			want: "d/f",
+		},
+		{
+			name: "CreatePerm",
+			run: func(fs fsmocker.Stub) (string, error) {
+				_, err := withFile("/d/new", os.O_WRONLY|os.O_CREATE, func(f fsmocker.File) (string, error) {
+					return "", nil
+				})(fs)
+				return state(fs, "/d/new"), err
+			},
+			want: `file 0 -rw------- ""`,
+		},
+		{
+			name: "CreateKeepsPerm",
+			run: func(fs fsmocker.Stub) (string, error) {
+				_, err := withFile("/d/f", os.O_WRONLY|os.O_CREATE, func(f fsmocker.File) (string, error) {
+					return "", nil
+				})(fs)
+				return state(fs, "/d/f"), err
+			},
+			want: `file 4 -rw-r--r-- "data"`,
+		},
+		{
+			name: "WriteFileKeepsPerm",
+			run: func(fs fsmocker.Stub) (string, error) {
+				return then(fs, fs.WriteFile("/d/f", []byte("x"), 0600), "/d/f")
+			},
+			want: `ok, file 1 -rw-r--r-- "x"`,
+		},
+		{
+			name: "RemoveOpen",
+			run: func(fs fsmocker.Stub) (string, error) {
+				return withFile("/d/f", os.O_RDONLY, func(f fsmocker.File) (string, error) {
+					if err := fs.Remove("/d/f"); err != nil {
+						return "", err
+					}
+					return read(f, 10)
+				})(fs)
+			},
+			want: `"data"`,
+		},
+	}
+}
@@ -0,0 +1,754 @@
+package fsmockertest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shebang-go/fsmocker"
+)
+
+// kinds are the paths the operations of pathOps are applied to.
+var kinds = []struct{ kind, path string }{
+	{"file", "/d/f"},
+	{"dir", "/d"},
+	{"emptyDir", "/d/e"},
+	{"missing", "/d/x"},
+	{"noParent", "/x/y"},
+	{"fileParent", "/d/f/y"},
+	{"dotDot", "/d/e/../f"},
+	{"dirSlash", "/d/e/"},
+	{"relFile", "d/f"},
+	{"relDir", "d"},
+	{"nested", "/d/sub/g"},
+	{"root", "/"},
+}
+
+// pathOp is an operation on a single path. want holds the expected result
+// by kind, kinds without a result are not tested.
+type pathOp struct {
+	name string
+	run  func(fs fsmocker.Stub, p string) (string, error)
+	want map[string]string
+}
+
+func pathScenarios() []scenario {
+	scenarios := []scenario{}
+	for _, op := range pathOps {
+		op := op
+		for _, k := range kinds {
+			want, ok := op.want[k.kind]
+			if !ok {
+				continue
+			}
+			p := k.path
+			scenarios = append(scenarios, scenario{
+				name: op.name + "/" + k.kind,
+				run:  func(fs fsmocker.Stub) (string, error) { return op.run(fs, p) },
+				want: want,
+			})
+		}
+	}
+	return scenarios
+}
+
+// walk returns the paths visited by Walk and the errors passed to walkFn.
+func walk(fs fsmocker.Stub, root string, skip string) (string, error) {
+	visited := []string{}
+	err := fs.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			visited = append(visited, path+"("+describeErr(err)+")")
+			return err
+		}
+		visited = append(visited, path)
+		if path == skip {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "[" + strings.Join(visited, " ") + "]", nil
+}
+
+// openFile opens p with flag, writes data if it is not nil and returns the
+// state of p after closing the file.
+func openFile(fs fsmocker.Stub, p string, flag int, data []byte) (string, error) {
+	f, err := fs.OpenFile(p, flag, 0600)
+	if err != nil {
+		return "", err
+	}
+	if data != nil {
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return "", err
+		}
+	}
+	return then(fs, f.Close(), p)
+}
+
+var pathOps = []pathOp{
+	{
+		name: "Stat",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			fi, err := fs.Stat(p)
+			if err != nil {
+				return "", err
+			}
+			return fi.Name() + " " + describe(fi), nil
+		},
+		want: map[string]string{
+			"file":       "f file 4 -rw-r--r--",
+			"dir":        "d dir drwxr-xr-x",
+			"emptyDir":   "e dir drwxr-xr-x",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "f file 4 -rw-r--r--",
+			"dirSlash":   "e dir drwxr-xr-x",
+			"relFile":    "f file 4 -rw-r--r--",
+			"relDir":     "d dir drwxr-xr-x",
+			"nested":     "g file 1 -rw-r--r--",
+		},
+	},
+	{
+		name: "ReadFile",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			data, err := fs.ReadFile(p)
+			return fmt.Sprintf("%q", data), err
+		},
+		want: map[string]string{
+			"file":       `"data"`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `"data"`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `"data"`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `"g"`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "ReadDir",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			entries, err := fs.ReadDir(p)
+			return names(entries), err
+		},
+		want: map[string]string{
+			"file":       "PathError ENOTDIR",
+			"dir":        "[e f sub]",
+			"emptyDir":   "[]",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "PathError ENOTDIR",
+			"dirSlash":   "[]",
+			"relFile":    "PathError ENOTDIR",
+			"relDir":     "[e f sub]",
+			"nested":     "PathError ENOTDIR",
+			"root":       "[d h]",
+		},
+	},
+	{
+		name: "WriteFile",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.WriteFile(p, []byte("new"), 0600), p)
+		},
+		want: map[string]string{
+			"file":       `ok, file 3 -rw-r--r-- "new"`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    `ok, file 3 -rw------- "new"`,
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, file 3 -rw-r--r-- "new"`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `ok, file 3 -rw-r--r-- "new"`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `ok, file 3 -rw-r--r-- "new"`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "Mkdir",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.Mkdir(p, 0700), p)
+		},
+		want: map[string]string{
+			"file":       "PathError EEXIST",
+			"dir":        "PathError EEXIST",
+			"emptyDir":   "PathError EEXIST",
+			"missing":    "ok, dir drwx------",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "PathError EEXIST",
+			"dirSlash":   "PathError EEXIST",
+			"relFile":    "PathError EEXIST",
+			"relDir":     "PathError EEXIST",
+			"nested":     "PathError EEXIST",
+			"root":       "PathError EEXIST",
+		},
+	},
+	{
+		name: "MkdirAll",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.MkdirAll(p, 0700), p)
+		},
+		want: map[string]string{
+			"file":       "PathError ENOTDIR",
+			"dir":        "ok, dir drwxr-xr-x",
+			"emptyDir":   "ok, dir drwxr-xr-x",
+			"missing":    "ok, dir drwx------",
+			"noParent":   "ok, dir drwx------",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "PathError ENOTDIR",
+			"dirSlash":   "ok, dir drwxr-xr-x",
+			"relFile":    "PathError ENOTDIR",
+			"relDir":     "ok, dir drwxr-xr-x",
+			"nested":     "PathError ENOTDIR",
+		},
+	},
+	{
+		name: "Remove",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.Remove(p), p)
+		},
+		want: map[string]string{
+			"file":       "ok, PathError ENOENT",
+			"dir":        "PathError ENOTEMPTY",
+			"emptyDir":   "ok, PathError ENOENT",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "ok, PathError ENOENT",
+			"dirSlash":   "ok, PathError ENOENT",
+			"relFile":    "ok, PathError ENOENT",
+			"relDir":     "PathError ENOTEMPTY",
+			"nested":     "ok, PathError ENOENT",
+			"root":       "PathError ENOTEMPTY",
+		},
+	},
+	{
+		name: "RemoveAll",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.RemoveAll(p), p)
+		},
+		want: map[string]string{
+			"file":       "ok, PathError ENOENT",
+			"dir":        "ok, PathError ENOENT",
+			"emptyDir":   "ok, PathError ENOENT",
+			"missing":    "ok, PathError ENOENT",
+			"noParent":   "ok, PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "ok, PathError ENOENT",
+			"dirSlash":   "ok, PathError ENOENT",
+			"relFile":    "ok, PathError ENOENT",
+			"relDir":     "ok, PathError ENOENT",
+			"nested":     "ok, PathError ENOENT",
+		},
+	},
+	{
+		name: "Truncate",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.Truncate(p, 2), p)
+		},
+		want: map[string]string{
+			"file":       `ok, file 2 -rw-r--r-- "da"`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, file 2 -rw-r--r-- "da"`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `ok, file 2 -rw-r--r-- "da"`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `ok, file 2 -rw-r--r-- "g\x00"`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "TruncateExtend",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.Truncate(p, 6), p)
+		},
+		want: map[string]string{
+			"file":       `ok, file 6 -rw-r--r-- "data\x00\x00"`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, file 6 -rw-r--r-- "data\x00\x00"`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `ok, file 6 -rw-r--r-- "data\x00\x00"`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `ok, file 6 -rw-r--r-- "g\x00\x00\x00\x00\x00"`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "Chmod",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return then(fs, fs.Chmod(p, 0700), p)
+		},
+		want: map[string]string{
+			"file":       `ok, file 4 -rwx------ "data"`,
+			"dir":        "ok, dir drwx------",
+			"emptyDir":   "ok, dir drwx------",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, file 4 -rwx------ "data"`,
+			"dirSlash":   "ok, dir drwx------",
+			"relFile":    `ok, file 4 -rwx------ "data"`,
+			"relDir":     "ok, dir drwx------",
+			"nested":     `ok, file 1 -rwx------ "g"`,
+			"root":       "ok, dir drwx------",
+		},
+	},
+	{
+		name: "Open",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			f, err := fs.Open(p)
+			if err != nil {
+				return "", err
+			}
+			defer f.Close()
+			fi, err := f.Stat()
+			if err != nil {
+				return "", err
+			}
+			return describe(fi), nil
+		},
+		want: map[string]string{
+			"file":       "file 4 -rw-r--r--",
+			"dir":        "dir drwxr-xr-x",
+			"emptyDir":   "dir drwxr-xr-x",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "file 4 -rw-r--r--",
+			"dirSlash":   "dir drwxr-xr-x",
+			"relFile":    "file 4 -rw-r--r--",
+			"relDir":     "dir drwxr-xr-x",
+			"nested":     "file 1 -rw-r--r--",
+		},
+	},
+	{
+		name: "Create",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			f, err := fs.Create(p)
+			if err != nil {
+				return "", err
+			}
+			if _, err := f.Write([]byte("new")); err != nil {
+				f.Close()
+				return "", err
+			}
+			if err := f.Close(); err != nil {
+				return "", err
+			}
+			// the mode of the new file depends on the umask
+			data, err := fs.ReadFile(p)
+			return fmt.Sprintf("ok, %q", data), err
+		},
+		want: map[string]string{
+			"file":       `ok, "new"`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    `ok, "new"`,
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, "new"`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `ok, "new"`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `ok, "new"`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "OpenExcl",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return openFile(fs, p, os.O_RDWR|os.O_CREATE|os.O_EXCL, nil)
+		},
+		want: map[string]string{
+			"file":       "PathError EEXIST",
+			"dir":        "PathError EEXIST",
+			"emptyDir":   "PathError EEXIST",
+			"missing":    `ok, file 0 -rw------- ""`,
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "PathError EEXIST",
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    "PathError EEXIST",
+			"relDir":     "PathError EEXIST",
+			"nested":     "PathError EEXIST",
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "OpenAppend",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return openFile(fs, p, os.O_WRONLY|os.O_APPEND, []byte("+"))
+		},
+		want: map[string]string{
+			"file":       `ok, file 5 -rw-r--r-- "data+"`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, file 5 -rw-r--r-- "data+"`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `ok, file 5 -rw-r--r-- "data+"`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `ok, file 2 -rw-r--r-- "g+"`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "OpenTrunc",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return openFile(fs, p, os.O_WRONLY|os.O_TRUNC, nil)
+		},
+		want: map[string]string{
+			"file":       `ok, file 0 -rw-r--r-- ""`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, file 0 -rw-r--r-- ""`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `ok, file 0 -rw-r--r-- ""`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `ok, file 0 -rw-r--r-- ""`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "OpenWrite",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return openFile(fs, p, os.O_WRONLY, []byte("DA"))
+		},
+		want: map[string]string{
+			"file":       `ok, file 4 -rw-r--r-- "DAta"`,
+			"dir":        "PathError EISDIR",
+			"emptyDir":   "PathError EISDIR",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     `ok, file 4 -rw-r--r-- "DAta"`,
+			"dirSlash":   "PathError EISDIR",
+			"relFile":    `ok, file 4 -rw-r--r-- "DAta"`,
+			"relDir":     "PathError EISDIR",
+			"nested":     `ok, file 2 -rw-r--r-- "DA"`,
+			"root":       "PathError EISDIR",
+		},
+	},
+	{
+		name: "Walk",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return walk(fs, p, "")
+		},
+		want: map[string]string{
+			"file":       "[/d/f]",
+			"dir":        "[/d /d/e /d/f /d/sub /d/sub/g]",
+			"emptyDir":   "[/d/e]",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "[/d/e/../f]",
+			"dirSlash":   "[/d/e/]",
+			"relFile":    "[d/f]",
+			"relDir":     "[d d/e d/f d/sub d/sub/g]",
+			"nested":     "[/d/sub/g]",
+			"root":       "[/ /d /d/e /d/f /d/sub /d/sub/g /h]",
+		},
+	},
+	{
+		name: "WalkSkipDir",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			return walk(fs, p, filepath.Join(p, "sub"))
+		},
+		want: map[string]string{
+			"file":       "[/d/f]",
+			"dir":        "[/d /d/e /d/f /d/sub]",
+			"emptyDir":   "[/d/e]",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "[/d/e/../f]",
+			"dirSlash":   "[/d/e/]",
+			"relFile":    "[d/f]",
+			"relDir":     "[d d/e d/f d/sub]",
+			"nested":     "[/d/sub/g]",
+			"root":       "[/ /d /d/e /d/f /d/sub /d/sub/g /h]",
+		},
+	},
+	{
+		name: "Chdir",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			if err := fs.Chdir(p); err != nil {
+				return "", err
+			}
+			wd, err := fs.Getwd()
+			if err != nil {
+				return "", err
+			}
+			entries, err := fs.ReadDir(".")
+			return wd + " " + names(entries), err
+		},
+		want: map[string]string{
+			"file":       "PathError ENOTDIR",
+			"dir":        "/d [e f sub]",
+			"emptyDir":   "/d/e []",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "PathError ENOTDIR",
+			"dirSlash":   "/d/e []",
+			"relFile":    "PathError ENOTDIR",
+			"relDir":     "/d [e f sub]",
+			"nested":     "PathError ENOTDIR",
+			"root":       "/ [d h]",
+		},
+	},
+	{
+		name: "Glob",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			matches, err := fs.Glob(p)
+			return fmt.Sprint(matches), err
+		},
+		want: map[string]string{
+			"file":       "[/d/f]",
+			"dir":        "[/d]",
+			"emptyDir":   "[/d/e]",
+			"missing":    "[]",
+			"noParent":   "[]",
+			"fileParent": "[]",
+			"dotDot":     "[/d/e/../f]",
+			"dirSlash":   "[/d/e/]",
+			"relFile":    "[d/f]",
+			"relDir":     "[d]",
+			"nested":     "[/d/sub/g]",
+			"root":       "[/]",
+		},
+	},
+	{
+		name: "MkdirTemp",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			name, err := fs.MkdirTemp(p, "tmp")
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v %s", strings.HasPrefix(filepath.Base(name), "tmp"), state(fs, name)), nil
+		},
+		want: map[string]string{
+			"file":       "PathError ENOTDIR",
+			"dir":        "true dir drwx------",
+			"emptyDir":   "true dir drwx------",
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "PathError ENOTDIR",
+			"dirSlash":   "true dir drwx------",
+			"relFile":    "PathError ENOTDIR",
+			"relDir":     "true dir drwx------",
+			"nested":     "PathError ENOTDIR",
+			"root":       "true dir drwx------",
+		},
+	},
+	{
+		name: "CreateTemp",
+		run: func(fs fsmocker.Stub, p string) (string, error) {
+			f, err := fs.CreateTemp(p, "tmp*.txt")
+			if err != nil {
+				return "", err
+			}
+			defer f.Close()
+			base := filepath.Base(f.Name())
+			ok := strings.HasPrefix(base, "tmp") && strings.HasSuffix(base, ".txt")
+			return fmt.Sprintf("%v %s", ok, state(fs, f.Name())), nil
+		},
+		want: map[string]string{
+			"file":       "PathError ENOTDIR",
+			"dir":        `true file 0 -rw------- ""`,
+			"emptyDir":   `true file 0 -rw------- ""`,
+			"missing":    "PathError ENOENT",
+			"noParent":   "PathError ENOENT",
+			"fileParent": "PathError ENOTDIR",
+			"dotDot":     "PathError ENOTDIR",
+			"dirSlash":   `true file 0 -rw------- ""`,
+			"relFile":    "PathError ENOTDIR",
+			"relDir":     `true file 0 -rw------- ""`,
+			"nested":     "PathError ENOTDIR",
+			"root":       `true file 0 -rw------- ""`,
+		},
+	},
+}
+
+// renames are the sources and destinations of the rename scenarios.
+var renames = []struct{ kind, path string }{
+	{"file", "/d/f"},
+	{"otherFile", "/h"},
+	{"dir", "/d"},
+	{"emptyDir", "/d/e"},
+	{"subDir", "/d/sub"},
+	{"missing", "/d/x"},
+	{"noParent", "/x/y"},
+	{"fileParent", "/d/f/y"},
+	{"child", "/d/sub/x"},
+}
+
+// renameWant holds the expected results of renaming a source to a
+// destination by their kinds ("src>dst").
+var renameWant = map[string]string{
+	"file>file":             `ok, file 4 -rw-r--r-- "data"; file 4 -rw-r--r-- "data"`,
+	"file>otherFile":        `ok, PathError ENOENT; file 4 -rw-r--r-- "data"`,
+	"file>dir":              "LinkError EEXIST",
+	"file>emptyDir":         "LinkError EEXIST",
+	"file>subDir":           "LinkError EEXIST",
+	"file>missing":          `ok, PathError ENOENT; file 4 -rw-r--r-- "data"`,
+	"file>noParent":         "LinkError ENOENT",
+	"file>fileParent":       "LinkError ENOTDIR",
+	"file>child":            `ok, PathError ENOENT; file 4 -rw-r--r-- "data"`,
+	"otherFile>file":        `ok, PathError ENOENT; file 1 -rw-r--r-- "h"`,
+	"otherFile>otherFile":   `ok, file 1 -rw-r--r-- "h"; file 1 -rw-r--r-- "h"`,
+	"otherFile>dir":         "LinkError EEXIST",
+	"otherFile>emptyDir":    "LinkError EEXIST",
+	"otherFile>subDir":      "LinkError EEXIST",
+	"otherFile>missing":     `ok, PathError ENOENT; file 1 -rw-r--r-- "h"`,
+	"otherFile>noParent":    "LinkError ENOENT",
+	"otherFile>fileParent":  "LinkError ENOTDIR",
+	"otherFile>child":       `ok, PathError ENOENT; file 1 -rw-r--r-- "h"`,
+	"dir>file":              "LinkError EINVAL",
+	"dir>otherFile":         "LinkError ENOTDIR",
+	"dir>dir":               "LinkError EEXIST",
+	"dir>emptyDir":          "LinkError EEXIST",
+	"dir>subDir":            "LinkError EEXIST",
+	"dir>missing":           "LinkError EINVAL",
+	"dir>noParent":          "LinkError ENOENT",
+	"dir>fileParent":        "LinkError ENOTDIR",
+	"dir>child":             "LinkError EINVAL",
+	"emptyDir>file":         "LinkError ENOTDIR",
+	"emptyDir>otherFile":    "LinkError ENOTDIR",
+	"emptyDir>dir":          "LinkError EEXIST",
+	"emptyDir>emptyDir":     "LinkError EEXIST",
+	"emptyDir>subDir":       "LinkError EEXIST",
+	"emptyDir>missing":      "ok, PathError ENOENT; dir drwxr-xr-x",
+	"emptyDir>noParent":     "LinkError ENOENT",
+	"emptyDir>fileParent":   "LinkError ENOTDIR",
+	"emptyDir>child":        "ok, PathError ENOENT; dir drwxr-xr-x",
+	"subDir>file":           "LinkError ENOTDIR",
+	"subDir>otherFile":      "LinkError ENOTDIR",
+	"subDir>dir":            "LinkError EEXIST",
+	"subDir>emptyDir":       "LinkError EEXIST",
+	"subDir>subDir":         "LinkError EEXIST",
+	"subDir>missing":        "ok, PathError ENOENT; dir drwxr-xr-x",
+	"subDir>noParent":       "LinkError ENOENT",
+	"subDir>fileParent":     "LinkError ENOTDIR",
+	"subDir>child":          "LinkError EINVAL",
+	"missing>file":          "LinkError ENOENT",
+	"missing>otherFile":     "LinkError ENOENT",
+	"missing>dir":           "LinkError ENOENT",
+	"missing>emptyDir":      "LinkError ENOENT",
+	"missing>subDir":        "LinkError ENOENT",
+	"missing>missing":       "LinkError ENOENT",
+	"missing>noParent":      "LinkError ENOENT",
+	"missing>fileParent":    "LinkError ENOTDIR",
+	"missing>child":         "LinkError ENOENT",
+	"noParent>file":         "LinkError ENOENT",
+	"noParent>otherFile":    "LinkError ENOENT",
+	"noParent>dir":          "LinkError ENOENT",
+	"noParent>emptyDir":     "LinkError ENOENT",
+	"noParent>subDir":       "LinkError ENOENT",
+	"noParent>missing":      "LinkError ENOENT",
+	"noParent>noParent":     "LinkError ENOENT",
+	"noParent>fileParent":   "LinkError ENOENT",
+	"noParent>child":        "LinkError ENOENT",
+	"fileParent>file":       "LinkError ENOTDIR",
+	"fileParent>otherFile":  "LinkError ENOTDIR",
+	"fileParent>dir":        "LinkError ENOTDIR",
+	"fileParent>emptyDir":   "LinkError ENOTDIR",
+	"fileParent>subDir":     "LinkError ENOTDIR",
+	"fileParent>missing":    "LinkError ENOTDIR",
+	"fileParent>noParent":   "LinkError ENOTDIR",
+	"fileParent>fileParent": "LinkError ENOTDIR",
+	"fileParent>child":      "LinkError ENOTDIR",
+	"child>file":            "LinkError ENOENT",
+	"child>otherFile":       "LinkError ENOENT",
+	"child>dir":             "LinkError ENOENT",
+	"child>emptyDir":        "LinkError ENOENT",
+	"child>subDir":          "LinkError ENOENT",
+	"child>missing":         "LinkError ENOENT",
+	"child>noParent":        "LinkError ENOENT",
+	"child>fileParent":      "LinkError ENOTDIR",
+	"child>child":           "LinkError ENOENT",
+}
+
+func renameScenarios() []scenario {
+	scenarios := []scenario{}
+	for _, src := range renames {
+		for _, dst := range renames {
+			want, ok := renameWant[src.kind+">"+dst.kind]
+			if !ok {
+				continue
+			}
+			from, to := src.path, dst.path
+			scenarios = append(scenarios, scenario{
+				name: src.kind + ">" + dst.kind,
+				run: func(fs fsmocker.Stub) (string, error) {
+					if err := fs.Rename(from, to); err != nil {
+						return "", err
+					}
+					return "ok, " + state(fs, from) + "; " + state(fs, to), nil
+				},
+				want: want,
+			})
+		}
+	}
+	return scenarios
+}
+
+// globs are the patterns of the glob scenarios with their expected
+// matches.
+var globs = []struct{ pattern, want string }{
+	{"/d/*", "[/d/e /d/f /d/sub]"},
+	{"d/*", "[d/e d/f d/sub]"},
+	{"*", "[d h]"},
+	{"/*/g", "[]"},
+	{"/*/*/g", "[/d/sub/g]"},
+	{"/d/su?/*", "[/d/sub/g]"},
+	{"/d/[ef]", "[/d/e /d/f]"},
+	{"/d/[^e]", "[/d/f]"},
+	{"/d/\\f", "[/d/f]"},
+	{"/d/f/*", "[]"},
+	{"/x/*", "[]"},
+	{"/d/[", "ErrBadPattern"},
+	{"/d/[]", "ErrBadPattern"},
+}
+
+func globScenarios() []scenario {
+	scenarios := []scenario{}
+	for _, g := range globs {
+		pattern := g.pattern
+		scenarios = append(scenarios, scenario{
+			name: pattern,
+			run: func(fs fsmocker.Stub) (string, error) {
+				matches, err := fs.Glob(pattern)
+				return fmt.Sprint(matches), err
+			},
+			want: g.want,
+		})
+	}
+	return scenarios
+}
@@ -0,0 +1,186 @@
+package fsmockertest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shebang-go/fsmocker"
+)
+
+// symlink creates the links of the symlink scenarios:
+//
+//	/lf -> /d/f
+//	/ld -> d
+//	/d/lr -> f
+//	/dangling -> /d/x
+func symlink(fs fsmocker.Stub) (Symlinker, error) {
+	sl := fs.(Symlinker)
+	links := []struct{ target, name string }{{"/d/f", "/lf"}, {"d", "/ld"}, {"f", "/d/lr"}, {"/d/x", "/dangling"}}
+	for _, l := range links {
+		if err := sl.Symlink(l.target, l.name); err != nil {
+			return nil, err
+		}
+	}
+	return sl, nil
+}
+
+// withLinks runs fn after creating the links (see symlink).
+func withLinks(fn func(fs fsmocker.Stub, sl Symlinker) (string, error)) func(fs fsmocker.Stub) (string, error) {
+	return func(fs fsmocker.Stub) (string, error) {
+		sl, err := symlink(fs)
+		if err != nil {
+			return "", err
+		}
+		return fn(fs, sl)
+	}
+}
+
+// linkOps are the operations applied to the links and their targets with
+// the expected results by path.
+var linkOps = []struct {
+	name string
+	run  func(fs fsmocker.Stub, sl Symlinker, p string) (string, error)
+	want map[string]string
+}{
+	{
+		name: "Lstat",
+		run: func(fs fsmocker.Stub, sl Symlinker, p string) (string, error) {
+			fi, err := sl.Lstat(p)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s %v", fi.Name(), fi.Mode()&os.ModeType), nil
+		},
+		want: map[string]string{
+			"/lf":       "lf L---------",
+			"/ld":       "ld L---------",
+			"/d/lr":     "lr L---------",
+			"/dangling": "dangling L---------",
+			"/d/f":      "f ----------",
+			"/d":        "d d---------",
+			"/d/x":      "PathError ENOENT",
+		},
+	},
+	{
+		name: "Readlink",
+		run: func(fs fsmocker.Stub, sl Symlinker, p string) (string, error) {
+			return sl.Readlink(p)
+		},
+		want: map[string]string{
+			"/lf":       "/d/f",
+			"/ld":       "d",
+			"/d/lr":     "f",
+			"/dangling": "/d/x",
+			"/d/f":      "PathError EINVAL",
+			"/d":        "PathError EINVAL",
+			"/d/x":      "PathError ENOENT",
+		},
+	},
+	{
+		name: "Stat",
+		run: func(fs fsmocker.Stub, sl Symlinker, p string) (string, error) {
+			return state(fs, p), nil
+		},
+		want: map[string]string{
+			"/lf":       `file 4 -rw-r--r-- "data"`,
+			"/ld":       "dir drwxr-xr-x",
+			"/d/lr":     `file 4 -rw-r--r-- "data"`,
+			"/dangling": "PathError ENOENT",
+			"/d/f":      `file 4 -rw-r--r-- "data"`,
+			"/d":        "dir drwxr-xr-x",
+			"/d/x":      "PathError ENOENT",
+		},
+	},
+}
+
+func symlinkScenarios() []scenario {
+	scenarios := []scenario{}
+	for _, op := range linkOps {
+		op := op
+		for _, p := range []string{"/lf", "/ld", "/d/lr", "/dangling", "/d/f", "/d", "/d/x"} {
+			p := p
+			scenarios = append(scenarios, scenario{
+				name: op.name + p,
+				run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+					return op.run(fs, sl, p)
+				}),
+				want: op.want[p],
+			})
+		}
+	}
+	return append(scenarios, []scenario{
+		{
+			name: "SymlinkExists",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				return "", sl.Symlink("/h", "/lf")
+			}),
+			want: "LinkError EEXIST",
+		},
+		{
+			name: "SymlinkNoParent",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				return "", sl.Symlink("/h", "/x/y")
+			}),
+			want: "LinkError ENOENT",
+		},
+		{
+			name: "ReadDirLink",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				entries, err := fs.ReadDir("/ld")
+				return names(entries), err
+			}),
+			want: "[e f lr sub]",
+		},
+		{
+			name: "WriteThroughLink",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				return then(fs, fs.WriteFile("/lf", []byte("new"), 0600), "/d/f")
+			}),
+			want: `ok, file 3 -rw-r--r-- "new"`,
+		},
+		{
+			name: "WriteDangling",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				return then(fs, fs.WriteFile("/dangling", []byte("new"), 0600), "/d/x")
+			}),
+			want: `ok, file 3 -rw------- "new"`,
+		},
+		{
+			name: "RemoveLink",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				if err := fs.Remove("/lf"); err != nil {
+					return "", err
+				}
+				return state(fs, "/d/f"), nil
+			}),
+			want: `file 4 -rw-r--r-- "data"`,
+		},
+		{
+			name: "RemoveAllLinkedDir",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				if err := fs.RemoveAll("/ld"); err != nil {
+					return "", err
+				}
+				return state(fs, "/d/f"), nil
+			}),
+			want: `file 4 -rw-r--r-- "data"`,
+		},
+		{
+			name: "RenameLink",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				if err := fs.Rename("/d/lr", "/lr"); err != nil {
+					return "", err
+				}
+				return state(fs, "/lr"), nil
+			}),
+			want: "PathError ENOENT",
+		},
+		{
+			name: "WalkLinks",
+			run: withLinks(func(fs fsmocker.Stub, sl Symlinker) (string, error) {
+				return walk(fs, "/", "/d/sub")
+			}),
+			want: "[/ /d /d/e /d/f /d/lr /d/sub /dangling /h /ld /lf]",
+		},
+	}...)
+}
@@ -0,0 +1,84 @@
+// Package faket provides a testing.TB which records what is reported to it
+// instead of failing the test. It is used by the tests of fsmocker for code
+// which fails a test (ex: strict mode, expectations, replays).
+package faket
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// T records failures, log lines and cleanup functions. Other methods of
+// testing.TB panic.
+type T struct {
+	testing.TB
+	mu       sync.Mutex
+	errors   []string
+	logs     []string
+	cleanups []func()
+}
+
+// Helper does nothing.
+func (t *T) Helper() {}
+
+// Error records a failure formatted like fmt.Sprint.
+func (t *T) Error(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+// Errorf records a failure formatted like fmt.Sprintf.
+func (t *T) Errorf(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// Log records a log line formatted like fmt.Sprint.
+func (t *T) Log(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
+
+// Logf records a log line formatted like fmt.Sprintf.
+func (t *T) Logf(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logs = append(t.logs, fmt.Sprintf(format, args...))
+}
+
+// Cleanup records fn, it is called by RunCleanups.
+func (t *T) Cleanup(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cleanups = append(t.cleanups, fn)
+}
+
+// RunCleanups calls the functions recorded by Cleanup in reverse order, like
+// at the end of a test.
+func (t *T) RunCleanups() {
+	t.mu.Lock()
+	cleanups := t.cleanups
+	t.cleanups = nil
+	t.mu.Unlock()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+}
+
+// Errors returns the recorded failures, nil if there are none.
+func (t *T) Errors() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.errors...)
+}
+
+// Logs returns the recorded log lines, nil if there are none.
+func (t *T) Logs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.logs...)
+}
@@ -0,0 +1,100 @@
+// Package matchers provides argument matchers for mock expectations (see
+// stub.Expectation.With).
+package matchers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Matcher matches an argument of a call.
+type Matcher interface {
+	// Matches returns true if x is matched.
+	Matches(x interface{}) bool
+	// String describes the matcher.
+	String() string
+}
+
+type matcher struct {
+	desc string
+	fn   func(x interface{}) bool
+}
+
+func (m *matcher) Matches(x interface{}) bool { return m.fn(x) }
+func (m *matcher) String() string           { return m.desc }
+
+// Func returns a matcher which uses fn. desc describes the matcher in
+// failure messages.
+func Func(desc string, fn func(x interface{}) bool) Matcher {
+	return &matcher{desc: desc, fn: fn}
+}
+
+// Any matches any value.
+func Any() Matcher {
+	return Func("any", func(x interface{}) bool { return true })
+}
+
+// Eq matches values equal to v. []byte and string are compared by content.
+func Eq(v interface{}) Matcher {
+	return Func(fmt.Sprintf("eq(%v)", format(v)), func(x interface{}) bool {
+		if s, ok := asString(v); ok {
+			if xs, ok := asString(x); ok {
+				return s == xs
+			}
+		}
+		return reflect.DeepEqual(v, x)
+	})
+}
+
+// Contains matches a string or []byte containing s.
+func Contains(s string) Matcher {
+	return Func(fmt.Sprintf("contains(%q)", s), func(x interface{}) bool {
+		xs, ok := asString(x)
+		return ok && strings.Contains(xs, s)
+	})
+}
+
+// HasPrefix matches a string or []byte starting with s.
+func HasPrefix(s string) Matcher {
+	return Func(fmt.Sprintf("hasPrefix(%q)", s), func(x interface{}) bool {
+		xs, ok := asString(x)
+		return ok && strings.HasPrefix(xs, s)
+	})
+}
+
+// Len matches a string, slice or map of length n.
+func Len(n int) Matcher {
+	return Func(fmt.Sprintf("len(%d)", n), func(x interface{}) bool {
+		v := reflect.ValueOf(x)
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			return v.Len() == n
+		}
+		return false
+	})
+}
+
+// Not matches values not matched by m.
+func Not(m Matcher) Matcher {
+	return Func(fmt.Sprintf("not(%s)", m), func(x interface{}) bool {
+		return !m.Matches(x)
+	})
+}
+
+func asString(x interface{}) (string, bool) {
+	switch v := x.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+func format(v interface{}) string {
+	if s, ok := asString(v); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,38 @@
+package matchers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher Matcher
+		x       interface{}
+		want    bool
+		desc    string
+	}{
+		{name: "any", matcher: Any(), x: nil, want: true, desc: "any"},
+		{name: "eqBytes", matcher: Eq("ok"), x: []byte("ok"), want: true, desc: `eq("ok")`},
+		{name: "eqMode", matcher: Eq(os.FileMode(0644)), x: os.FileMode(0644), want: true, desc: "eq(-rw-r--r--)"},
+		{name: "eqMismatch", matcher: Eq(1), x: 2, want: false, desc: "eq(1)"},
+		{name: "contains", matcher: Contains("ok"), x: []byte("status: ok\n"), want: true, desc: `contains("ok")`},
+		{name: "containsMismatch", matcher: Contains("ok"), x: "failed", want: false},
+		{name: "containsWrongType", matcher: Contains("1"), x: 1, want: false},
+		{name: "hasPrefix", matcher: HasPrefix("#!"), x: "#!/bin/sh", want: true, desc: `hasPrefix("#!")`},
+		{name: "len", matcher: Len(2), x: []byte("ok"), want: true, desc: "len(2)"},
+		{name: "lenWrongType", matcher: Len(2), x: 2, want: false},
+		{name: "not", matcher: Not(Contains("error")), x: "ok", want: true, desc: `not(contains("error"))`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.matcher.Matches(tt.x))
+			if tt.desc != "" {
+				assert.Equal(t, tt.desc, tt.matcher.String())
+			}
+		})
+	}
+}
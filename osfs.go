@@ -0,0 +1,382 @@
+package fsmocker
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/shebang-go/fsmocker/file"
+	"github.com/shebang-go/fsmocker/stub"
+)
+
+// OsFS implements Stub with the os, ioutil and filepath packages, so
+// applications can depend on Stub and use a stub in tests.
+//
+// An OsFS may be rooted at a base directory: paths are then relative to the
+// base, / is the base and paths can not leave it. Paths returned by the
+// OsFS, also in errors, are relative to the base as well.
+type OsFS struct {
+	base string
+	mu   sync.Mutex
+	// cwd is the working directory below base (see Chdir)
+	cwd string
+}
+
+// NewOsFS creates an OsFS rooted at base. Paths are used as they are if base
+// is empty.
+func NewOsFS(base string) *OsFS {
+	if base != "" {
+		if abs, err := filepath.Abs(base); err == nil {
+			base = abs
+		}
+	}
+	return &OsFS{base: base}
+}
+
+// Base returns the base directory of the OsFS.
+func (o *OsFS) Base() string {
+	return o.base
+}
+
+func (o *OsFS) getwd() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cwd == "" {
+		return string(os.PathSeparator)
+	}
+	return o.cwd
+}
+
+// abs returns the absolute path of p below base.
+func (o *OsFS) abs(p string) string {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(o.getwd(), p)
+	}
+	return filepath.Clean(string(os.PathSeparator) + p)
+}
+
+// real returns the path of p in the real file system. p is not cleaned, so
+// the OS resolves components like .. (ex: /dir/file/.. fails with ENOTDIR).
+// Paths which leave the base are cleaned, the base is its own parent.
+func (o *OsFS) real(p string) string {
+	if o.base == "" || p == "" {
+		return p
+	}
+	if !filepath.IsAbs(p) {
+		p = strings.TrimSuffix(o.getwd(), string(os.PathSeparator)) + string(os.PathSeparator) + p
+	}
+	if escapes(p) {
+		p = o.abs(p)
+	}
+	return o.base + p
+}
+
+// escapes returns true if the absolute path p leaves / by its .. components.
+func escapes(p string) bool {
+	depth := 0
+	for _, name := range strings.Split(p, string(os.PathSeparator)) {
+		switch name {
+		case "", ".":
+		case "..":
+			if depth--; depth < 0 {
+				return true
+			}
+		default:
+			depth++
+		}
+	}
+	return false
+}
+
+// rel returns the path of the real path p below base.
+func (o *OsFS) rel(p string) string {
+	if o.base == "" {
+		return p
+	}
+	if strings.HasPrefix(p, o.base+string(os.PathSeparator)) {
+		return p[len(o.base):]
+	}
+	rel, err := filepath.Rel(o.base, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return p
+	}
+	return filepath.Join(string(os.PathSeparator), rel)
+}
+
+// relErr replaces real paths in err with paths below base.
+func (o *OsFS) relErr(err error) error {
+	if o.base == "" {
+		return err
+	}
+	switch e := err.(type) {
+	case *os.PathError:
+		return &os.PathError{Op: e.Op, Path: o.rel(e.Path), Err: e.Err}
+	case *os.LinkError:
+		return &os.LinkError{Op: e.Op, Old: o.rel(e.Old), New: o.rel(e.New), Err: e.Err}
+	}
+	return err
+}
+
+// Config returns nil, files of the real file system can not be configured.
+func (o *OsFS) Config(p string) file.Configer {
+	return nil
+}
+
+// Options ignores opts, they apply to stubs only.
+func (o *OsFS) Options(opts ...stub.Option) {}
+
+// Stat calls os.Stat.
+func (o *OsFS) Stat(path string) (os.FileInfo, error) {
+	fi, err := os.Stat(o.real(path))
+	return fi, o.relErr(err)
+}
+
+// StatContext is like Stat, but returns ctx.Err() if ctx is done.
+func (o *OsFS) StatContext(ctx context.Context, path string) (os.FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return o.Stat(path)
+}
+
+// ReadFile calls ioutil.ReadFile.
+func (o *OsFS) ReadFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(o.real(path))
+	return data, o.relErr(err)
+}
+
+// ReadFileContext is like ReadFile, but returns ctx.Err() if ctx is done.
+func (o *OsFS) ReadFileContext(ctx context.Context, path string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return o.ReadFile(path)
+}
+
+// ReadDir calls ioutil.ReadDir.
+func (o *OsFS) ReadDir(path string) ([]os.FileInfo, error) {
+	entries, err := ioutil.ReadDir(o.real(path))
+	return entries, o.relErr(err)
+}
+
+// Walk calls filepath.Walk. Paths passed to walkFn are based on root as
+// given.
+func (o *OsFS) Walk(root string, walkFn filepath.WalkFunc) error {
+	realRoot := o.real(root)
+	err := filepath.Walk(realRoot, func(path string, info os.FileInfo, err error) error {
+		if rel, e := filepath.Rel(realRoot, path); e == nil && rel != "." {
+			path = filepath.Join(root, rel)
+		} else if e == nil {
+			path = root
+		}
+		return walkFn(path, info, o.relErr(err))
+	})
+	return o.relErr(err)
+}
+
+// Glob calls filepath.Glob. Matches of relative patterns are relative.
+func (o *OsFS) Glob(pattern string) ([]string, error) {
+	if o.base != "" && !strings.ContainsAny(pattern, `*?[\`) {
+		// like filepath.Glob, a pattern without meta characters matches
+		// itself as given
+		if _, err := os.Lstat(o.real(pattern)); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+	matches, err := filepath.Glob(o.real(pattern))
+	if err != nil || o.base == "" {
+		return matches, err
+	}
+	cwd := o.real(".")
+	for i, m := range matches {
+		if filepath.IsAbs(pattern) {
+			matches[i] = o.rel(m)
+		} else if rel, err := filepath.Rel(cwd, m); err == nil {
+			matches[i] = rel
+		}
+	}
+	return matches, nil
+}
+
+// WriteFile calls ioutil.WriteFile.
+func (o *OsFS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return o.relErr(ioutil.WriteFile(o.real(filename), data, perm))
+}
+
+// Truncate calls os.Truncate.
+func (o *OsFS) Truncate(name string, size int64) error {
+	return o.relErr(os.Truncate(o.real(name), size))
+}
+
+// Chmod calls os.Chmod.
+func (o *OsFS) Chmod(name string, mode os.FileMode) error {
+	return o.relErr(os.Chmod(o.real(name), mode))
+}
+
+// Rename calls os.Rename.
+func (o *OsFS) Rename(oldpath, newpath string) error {
+	return o.relErr(os.Rename(o.real(oldpath), o.real(newpath)))
+}
+
+// Open calls os.Open.
+func (o *OsFS) Open(name string) (File, error) {
+	return o.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Create calls os.Create.
+func (o *OsFS) Create(name string) (File, error) {
+	return o.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile calls os.OpenFile.
+func (o *OsFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(o.real(name), flag, perm)
+	if err != nil {
+		return nil, o.relErr(err)
+	}
+	return file.NewOsFile(f, name, nil), nil
+}
+
+// Mkdir calls os.Mkdir.
+func (o *OsFS) Mkdir(name string, perm os.FileMode) error {
+	return o.relErr(os.Mkdir(o.real(name), perm))
+}
+
+// MkdirAll calls os.MkdirAll.
+func (o *OsFS) MkdirAll(name string, perm os.FileMode) error {
+	return o.relErr(os.MkdirAll(o.real(name), perm))
+}
+
+// Remove calls os.Remove.
+func (o *OsFS) Remove(name string) error {
+	return o.relErr(os.Remove(o.real(name)))
+}
+
+// RemoveAll calls os.RemoveAll.
+func (o *OsFS) RemoveAll(name string) error {
+	return o.relErr(os.RemoveAll(o.real(name)))
+}
+
+// TempDir returns os.TempDir, or /tmp if the OsFS is rooted.
+func (o *OsFS) TempDir() string {
+	if o.base == "" {
+		return os.TempDir()
+	}
+	return filepath.Join(string(os.PathSeparator), "tmp")
+}
+
+// tempDir returns the real directory for temporary files in dir. The
+// default directory of a rooted OsFS is created if it does not exist.
+func (o *OsFS) tempDir(dir string) (string, error) {
+	if dir != "" || o.base == "" {
+		return o.real(dir), nil
+	}
+	dir = o.real(o.TempDir())
+	return dir, os.MkdirAll(dir, 0777)
+}
+
+// CreateTemp calls ioutil.TempFile.
+func (o *OsFS) CreateTemp(dir, pattern string) (File, error) {
+	dir, err := o.tempDir(dir)
+	if err != nil {
+		return nil, o.relErr(err)
+	}
+	f, err := ioutil.TempFile(dir, pattern)
+	if err != nil {
+		return nil, o.relErr(err)
+	}
+	return file.NewOsFile(f, o.rel(f.Name()), nil), nil
+}
+
+// MkdirTemp calls ioutil.TempDir.
+func (o *OsFS) MkdirTemp(dir, pattern string) (string, error) {
+	dir, err := o.tempDir(dir)
+	if err != nil {
+		return "", o.relErr(err)
+	}
+	name, err := ioutil.TempDir(dir, pattern)
+	return o.rel(name), o.relErr(err)
+}
+
+// Abs calls filepath.Abs.
+func (o *OsFS) Abs(p string) (string, error) {
+	if o.base == "" {
+		return filepath.Abs(p)
+	}
+	return o.abs(p), nil
+}
+
+// Chdir calls os.Chdir. A rooted OsFS keeps its own working directory and
+// does not change the one of the process.
+func (o *OsFS) Chdir(dir string) error {
+	if o.base == "" {
+		return os.Chdir(dir)
+	}
+	fi, err := os.Stat(o.real(dir))
+	if err != nil {
+		return o.relErr(err)
+	}
+	if !fi.IsDir() {
+		return &os.PathError{Op: "chdir", Path: dir, Err: syscall.ENOTDIR}
+	}
+	cwd := o.abs(dir)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.cwd = cwd
+	return nil
+}
+
+// Getwd calls os.Getwd.
+func (o *OsFS) Getwd() (string, error) {
+	if o.base == "" {
+		return os.Getwd()
+	}
+	return o.getwd(), nil
+}
+
+// FileInfo returns the FileInfo of p or nil if it can not be stat'ed.
+func (o *OsFS) FileInfo(p string) os.FileInfo {
+	fi, err := os.Stat(o.real(p))
+	if err != nil {
+		return nil
+	}
+	return fi
+}
+
+// Statfs returns the usage of the file system of name.
+func (o *OsFS) Statfs(name string) (file.DiskUsage, error) {
+	du, err := statfs(o.real(name))
+	return du, o.relErr(err)
+}
+
+// Symlink calls os.Symlink. Absolute targets of a rooted OsFS are below the
+// base.
+func (o *OsFS) Symlink(oldname, newname string) error {
+	if filepath.IsAbs(oldname) {
+		oldname = o.real(oldname)
+	}
+	return o.relErr(os.Symlink(oldname, o.real(newname)))
+}
+
+// Readlink calls os.Readlink.
+func (o *OsFS) Readlink(name string) (string, error) {
+	target, err := os.Readlink(o.real(name))
+	if err != nil {
+		return "", o.relErr(err)
+	}
+	if filepath.IsAbs(target) {
+		target = o.rel(target)
+	}
+	return target, nil
+}
+
+// Lstat calls os.Lstat.
+func (o *OsFS) Lstat(name string) (os.FileInfo, error) {
+	fi, err := os.Lstat(o.real(name))
+	return fi, o.relErr(err)
+}
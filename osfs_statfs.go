@@ -0,0 +1,28 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package fsmocker
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/shebang-go/fsmocker/file"
+)
+
+// statfs returns the usage of the file system of path.
+func statfs(path string) (file.DiskUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return file.DiskUsage{}, &os.PathError{Op: "statfs", Path: path, Err: err}
+	}
+	bsize := int64(st.Bsize)
+	return file.DiskUsage{
+		Bytes:      int64(st.Blocks) * bsize,
+		UsedBytes:  int64(st.Blocks-st.Bfree) * bsize,
+		FreeBytes:  int64(st.Bavail) * bsize,
+		Inodes:     int64(st.Files),
+		UsedInodes: int64(st.Files - st.Ffree),
+		FreeInodes: int64(st.Ffree),
+	}, nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package fsmocker
+
+import (
+	"errors"
+	"os"
+
+	"github.com/shebang-go/fsmocker/file"
+)
+
+// statfs is not supported on this platform.
+func statfs(path string) (file.DiskUsage, error) {
+	return file.DiskUsage{}, &os.PathError{Op: "statfs", Path: path, Err: errors.New("not supported")}
+}
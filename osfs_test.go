@@ -0,0 +1,132 @@
+package fsmocker
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Stub = &OsFS{}
+
+func rootedOsFS(t *testing.T) *OsFS {
+	base, err := ioutil.TempDir("", "osfs")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(base) })
+	o := NewOsFS(base)
+	assert.NoError(t, o.MkdirAll("/home/john", 0755))
+	assert.NoError(t, o.WriteFile("/home/john/file1", []byte("file1"), 0644))
+	return o
+}
+
+func TestOsFS_real(t *testing.T) {
+	tests := []struct {
+		name string
+		cwd  string
+		path string
+		want string
+	}{
+		{name: "abs", path: "/home/john/file1", want: "/home/john/file1"},
+		{name: "rel", cwd: "/home", path: "john/file1", want: "/home/john/file1"},
+		{name: "escapeAbs", path: "/../../etc/passwd", want: "/etc/passwd"},
+		{name: "escapeRel", cwd: "/home", path: "../../../etc/passwd", want: "/etc/passwd"},
+		{name: "dotDot", path: "/home/john/file1/..", want: "/home/john/file1/.."},
+		{name: "dotDotRel", cwd: "/home", path: "john/../john/", want: "/home/john/../john/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &OsFS{base: "/base", cwd: tt.cwd}
+			assert.Equal(t, "/base"+tt.want, o.real(tt.path))
+		})
+	}
+}
+
+func TestOsFS_rooted(t *testing.T) {
+	o := rootedOsFS(t)
+
+	data, err := o.ReadFile("/home/john/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, "file1", string(data))
+	_, err = ioutil.ReadFile(filepath.Join(o.Base(), "home", "john", "file1"))
+	assert.NoError(t, err)
+
+	_, err = o.Stat("/home/missing")
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "/home/missing", err.(*os.PathError).Path)
+	_, err = o.Stat("/home/john/file1/..")
+	assert.True(t, errors.Is(err, syscall.ENOTDIR), "got %v", err)
+	assert.Equal(t, "/home/john/file1/..", err.(*os.PathError).Path)
+
+	assert.NoError(t, o.Chdir("/home"))
+	wd, _ := o.Getwd()
+	assert.Equal(t, "/home", wd)
+	abs, _ := o.Abs("john")
+	assert.Equal(t, "/home/john", abs)
+	matches, err := o.Glob("john/*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"john/file1"}, matches)
+	matches, err = o.Glob("/home/*/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/home/john/file1"}, matches)
+
+	walked := []string{}
+	assert.NoError(t, o.Walk("john", func(path string, info os.FileInfo, err error) error {
+		walked = append(walked, path)
+		return err
+	}))
+	assert.Equal(t, []string{"john", "john/file1"}, walked)
+	walked = []string{}
+	assert.NoError(t, o.Walk("john/", func(path string, info os.FileInfo, err error) error {
+		walked = append(walked, path)
+		return err
+	}))
+	assert.Equal(t, []string{"john/", "john/file1"}, walked)
+	matches, err = o.Glob("john/./file1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"john/./file1"}, matches)
+
+	f, err := o.Open("john/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, "john/file1", f.Name())
+	buf := make([]byte, 10)
+	n, err := f.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "file1", string(buf[:n]))
+	assert.NoError(t, f.Close())
+
+	err = o.Rename("john/file1", "/missing/file1")
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "/missing/file1", err.(*os.LinkError).New)
+
+	tmp, err := o.CreateTemp("", "x*")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(tmp.Name(), "/tmp/x"), tmp.Name())
+	assert.NoError(t, tmp.Close())
+
+	du, err := o.Statfs("/")
+	assert.NoError(t, err)
+	assert.True(t, du.Bytes > 0)
+}
+
+func TestOsFS_unrooted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osfs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	o := NewOsFS("")
+	name := filepath.Join(dir, "file1")
+
+	assert.NoError(t, o.WriteFile(name, []byte("data"), 0600))
+	fi, err := o.Stat(name)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode())
+	entries, err := o.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.NoError(t, o.Remove(name))
+	assert.Nil(t, o.FileInfo(name))
+}
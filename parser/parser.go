@@ -5,13 +5,24 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/shebang-go/fsmocker/file"
+	"github.com/shebang-go/fsmocker/testdouble"
 )
 
 var regexPathOpts *regexp.Regexp = regexp.MustCompile(`^(?P<path>.*)\((?P<tags>.*)\)$`)
-var regexTag *regexp.Regexp = regexp.MustCompile(`^(?P<tag>err|data|isdir)=(?P<value>.*)`)
+var regexTag *regexp.Regexp = regexp.MustCompile(`^(?P<tag>[a-z]+)=(?P<value>.*)`)
+
+// tags holds the supported tags
+var tags = map[string]bool{"err": true, "data": true, "isdir": true, "mtime": true, "seq": true, "exhausted": true,
+	"readchunk": true, "writechunk": true, "failafter": true, "failerr": true,
+	"delay": true, "transient": true}
+
+// exhaustedModes holds the values of tag exhausted
+var exhaustedModes = map[string]file.Exhausted{"repeat": file.RepeatLast, "cycle": file.Cycle, "fail": file.Fail}
 var regexFiles *regexp.Regexp = regexp.MustCompile(`^.*\[(?P<files>.*)\]$`)
 
 var regexFilename *regexp.Regexp = regexp.MustCompile(`^([\s\w\.-\:\?_]+)((\(|\[).*)`)
@@ -28,6 +39,27 @@ func splitString(v string, sep string) []string {
 	return retval
 }
 
+// splitFiles splits a list of files at commas which are not part of tags.
+func splitFiles(v string) []string {
+	retval := []string{}
+	depth := 0
+	start := 0
+	for i, c := range v {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				retval = append(retval, v[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(retval, v[start:])
+}
+
 // Parse returns a list of test files
 func Parse(v string) []*file.FileInfo {
 
@@ -40,7 +72,7 @@ func Parse(v string) []*file.FileInfo {
 
 		tags := parseTags(v)
 		newPath := filepath.Join(curPath, fname)
-		retval = append(retval, &file.FileInfo{FName: fname, FIsDir: tags.FIsDir, Error: tags.Error, Data: tags.Data, Path: newPath})
+		retval = append(retval, newFileInfo(tags, fname, newPath, tags.FIsDir))
 
 		files := parseFiles(v, newPath)
 		for _, fi := range files {
@@ -64,13 +96,22 @@ func parseTag(v string) (string, string) {
 
 	match := regexTag.FindStringSubmatch(v)
 	if len(match) == 3 {
-		if match[1] == "err" || match[1] == "data" || match[1] == "isdir" {
+		if tags[match[1]] {
 			return match[1], match[2]
 		}
 	}
 
 	return "", ""
 }
+
+// newFileInfo creates a file from the parsed tags.
+func newFileInfo(tags file.FileInfo, name string, path string, isDir bool) *file.FileInfo {
+	tags.FName = name
+	tags.Path = path
+	tags.FIsDir = isDir
+	return &tags
+}
+
 func parseTags(v string) file.FileInfo {
 
 	fi := file.FileInfo{FIsDir: true}
@@ -82,13 +123,34 @@ func parseTags(v string) file.FileInfo {
 
 			switch key {
 			case "err":
-				fi.Error = errors.New(value)
+				fi.Error = parseError(value)
 			case "data":
 				fi.Data = []byte(value)
 			case "isdir":
 				if value == "false" {
 					fi.FIsDir = false
 				}
+			case "mtime":
+				parseTime(value, &fi)
+			case "seq":
+				parseSequence(value, &fi)
+			case "exhausted":
+				if fi.Sequence == nil {
+					fi.Sequence = &file.Sequence{}
+				}
+				fi.Sequence.Exhausted = exhaustedModes[value]
+			case "readchunk":
+				fi.ReadChunk, _ = strconv.Atoi(value)
+			case "writechunk":
+				fi.WriteChunk, _ = strconv.Atoi(value)
+			case "failafter":
+				fi.FailAfter, _ = strconv.ParseInt(value, 10, 64)
+			case "failerr":
+				fi.FailError = parseError(value)
+			case "delay":
+				fi.Delay, _ = time.ParseDuration(value)
+			case "transient":
+				parseTransient(value, &fi)
 			}
 		}
 		return fi
@@ -96,17 +158,64 @@ func parseTags(v string) file.FileInfo {
 	return fi
 }
 
+// parseError returns the errno for names like EIO (see
+// testdouble.ParseErrno) and a new error otherwise.
+func parseError(v string) error {
+	if errno, ok := testdouble.ParseErrno(v); ok {
+		return errno
+	}
+	return errors.New(v)
+}
+
+// parseSequence parses steps separated by | where a step is either data or
+// an error prefixed by err: (ex: seq=v1|err:EIO|v2).
+func parseSequence(v string, fi *file.FileInfo) {
+	if fi.Sequence == nil {
+		fi.Sequence = &file.Sequence{}
+	}
+	for _, step := range strings.Split(v, "|") {
+		if strings.HasPrefix(step, "err:") {
+			fi.Sequence.Steps = append(fi.Sequence.Steps, file.Step{Err: parseError(strings.TrimPrefix(step, "err:"))})
+		} else {
+			fi.Sequence.Steps = append(fi.Sequence.Steps, file.Step{Data: []byte(step)})
+		}
+	}
+}
+
+// parseTime parses a modification time which is either relative to the time
+// the file is added (ex: -2h) or absolute (RFC3339).
+func parseTime(v string, fi *file.FileInfo) {
+	if d, err := time.ParseDuration(v); err == nil {
+		fi.FModTimeOffset = d
+		return
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		fi.FModTime = t
+	}
+}
+
 func parseFiles(input string, base string) []*file.FileInfo {
 
 	retval := make([]*file.FileInfo, 0)
 	match := regexFiles.FindStringSubmatch(input)
 	if len(match) == 2 {
-		rawFiles := splitString(match[1], ",")
+		rawFiles := splitFiles(match[1])
 		for _, v := range rawFiles {
 			fname := parseFilename(v)
 			tags := parseTags(v)
-			retval = append(retval, &file.FileInfo{FName: fname, FIsDir: false, Error: tags.Error, Data: tags.Data, Path: filepath.Join(base, fname)})
+			retval = append(retval, newFileInfo(tags, fname, filepath.Join(base, fname), false))
 		}
 	}
 	return retval
 }
+
+// parseTransient parses a transient error like EINTR:3 (fail the first 3
+// attempts of each operation with EINTR).
+func parseTransient(v string, fi *file.FileInfo) {
+	parts := strings.SplitN(v, ":", 2)
+	n := 1
+	if len(parts) == 2 {
+		n, _ = strconv.Atoi(parts[1])
+	}
+	fi.Transient = &file.Transient{Err: parseError(parts[0]), N: n}
+}
@@ -2,7 +2,9 @@ package parser
 
 import (
 	"errors"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/shebang-go/fsmocker/file"
 	"github.com/stretchr/testify/assert"
@@ -71,6 +73,65 @@ func TestParser_Parse(t *testing.T) {
 				{FName: "dir", FIsDir: true, Path: "/dir", Error: errors.New("test"), Data: []byte("test")},
 			},
 		},
+		{
+			name:  "fileWithRelativeMTime",
+			input: "dir[file1(mtime=-2h)]",
+			want: []*file.FileInfo{
+				{FName: "dir", FIsDir: true, Path: "/dir"},
+				{FName: "file1", Path: "/dir/file1", FModTimeOffset: -2 * time.Hour},
+			},
+		},
+		{
+			name:  "fileWithAbsoluteMTime",
+			input: "dir[file1(mtime=2021-01-02T10:00:00Z)]",
+			want: []*file.FileInfo{
+				{FName: "dir", FIsDir: true, Path: "/dir"},
+				{FName: "file1", Path: "/dir/file1", FModTime: time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "fileWithErrno",
+			input: "dir[file1(err=EIO)]",
+			want: []*file.FileInfo{
+				{FName: "dir", FIsDir: true, Path: "/dir"},
+				{FName: "file1", Path: "/dir/file1", Error: syscall.EIO},
+			},
+		},
+		{
+			name:  "fileWithSequence",
+			input: "dir[file1(seq=v1|err:EIO|v2, exhausted=cycle)]",
+			want: []*file.FileInfo{
+				{FName: "dir", FIsDir: true, Path: "/dir"},
+				{FName: "file1", Path: "/dir/file1", Sequence: &file.Sequence{
+					Steps:     []file.Step{{Data: []byte("v1")}, {Err: syscall.EIO}, {Data: []byte("v2")}},
+					Exhausted: file.Cycle,
+				}},
+			},
+		},
+		{
+			name:  "fileWithStreamFaults",
+			input: "dir[file1(readchunk=7, writechunk=3, failafter=1024, failerr=EIO)]",
+			want: []*file.FileInfo{
+				{FName: "dir", FIsDir: true, Path: "/dir"},
+				{FName: "file1", Path: "/dir/file1", ReadChunk: 7, WriteChunk: 3, FailAfter: 1024, FailError: syscall.EIO},
+			},
+		},
+		{
+			name:  "fileWithDelay",
+			input: "dir[file1(delay=200ms)]",
+			want: []*file.FileInfo{
+				{FName: "dir", FIsDir: true, Path: "/dir"},
+				{FName: "file1", Path: "/dir/file1", Delay: 200 * time.Millisecond},
+			},
+		},
+		{
+			name:  "fileWithTransientError",
+			input: "dir[file1(transient=EAGAIN:3)]",
+			want: []*file.FileInfo{
+				{FName: "dir", FIsDir: true, Path: "/dir"},
+				{FName: "file1", Path: "/dir/file1", Transient: &file.Transient{Err: syscall.EAGAIN, N: 3}},
+			},
+		},
 		{
 			name:  "dirWithInvalidTag",
 			input: "dir(err=test, invalid=test)",
@@ -0,0 +1,353 @@
+// Package record records the interactions of code with the real file system
+// and replays them with a stub (see NewRecorder and Replay).
+package record
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shebang-go/fsmocker/file"
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Recording holds the recorded calls.
+type Recording struct {
+	// Cwd is the working directory at the start of the recording
+	Cwd string `json:"cwd"`
+	// TempDir is the default directory of temporary files
+	TempDir string `json:"tempdir"`
+	// Calls holds the calls in the order they were made
+	Calls []Call `json:"calls"`
+}
+
+// Call is a recorded call and its result.
+type Call struct {
+	// Op is the operation named like in the spy (ex: ReadFile, File.Read)
+	Op string `json:"op"`
+	// Path is the absolute path of the call (the directory for CreateTemp
+	// and MkdirTemp, the pattern for Glob)
+	Path string `json:"path"`
+	// NewPath is the new path of Rename and the created path of
+	// CreateTemp and MkdirTemp
+	NewPath string      `json:"newpath,omitempty"`
+	Flag    int         `json:"flag,omitempty"`
+	Perm    os.FileMode `json:"perm,omitempty"`
+	Size    int64       `json:"size,omitempty"`
+	N       int         `json:"n,omitempty"`
+	// Err is the errno name (ex: ENOENT) or the message of the error
+	Err string `json:"err,omitempty"`
+	// Info is the result of Stat and the file opened by Open*
+	Info *Info `json:"info,omitempty"`
+	// Entries is the result of ReadDir and the files visited by Walk
+	Entries []Info `json:"entries,omitempty"`
+	// Data is the result of ReadFile and the content of a file opened for
+	// reading
+	Data []byte `json:"data,omitempty"`
+	// Matches is the result of Glob
+	Matches []string `json:"matches,omitempty"`
+}
+
+// Info describes a file.
+type Info struct {
+	Name    string      `json:"name"`
+	Path    string      `json:"path,omitempty"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime"`
+	IsDir   bool        `json:"isdir,omitempty"`
+}
+
+func newInfo(fi os.FileInfo) *Info {
+	return &Info{Name: fi.Name(), Size: fi.Size(), Mode: fi.Mode(), ModTime: fi.ModTime(), IsDir: fi.IsDir()}
+}
+
+// fileInfo returns the info as file of a stub at path.
+func (i *Info) fileInfo(path string) *file.FileInfo {
+	return &file.FileInfo{FName: i.Name, FSize: i.Size, FMode: i.Mode, FModTime: i.ModTime, FIsDir: i.IsDir, Path: path}
+}
+
+// encodeErr returns the errno name of err or its message.
+func encodeErr(err error) string {
+	if err == nil {
+		return ""
+	}
+	if name := testdouble.ErrnoName(testdouble.Errno(err)); name != "" {
+		return name
+	}
+	return err.Error()
+}
+
+// decodeErr returns the error encoded by encodeErr.
+func decodeErr(v string) error {
+	switch v {
+	case "":
+		return nil
+	case io.EOF.Error():
+		return io.EOF
+	}
+	if errno, ok := testdouble.ParseErrno(v); ok {
+		return errno
+	}
+	return errors.New(v)
+}
+
+// Save writes the recording as JSON to w.
+func (r *Recording) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// SaveFile writes the recording as JSON to the file name.
+func (r *Recording) SaveFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := r.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a recording written by Save.
+func Load(r io.Reader) (*Recording, error) {
+	rec := &Recording{}
+	if err := json.NewDecoder(r).Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// LoadFile reads a recording written by SaveFile.
+func LoadFile(name string) (*Recording, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Files returns the files as they were before the recorded calls changed
+// them, as far as the calls revealed them.
+func (r *Recording) Files() []*file.FileInfo {
+	t := newTree()
+	for _, c := range r.Calls {
+		t.add(c)
+	}
+	t.addParents(r.Cwd)
+	for _, c := range r.Calls {
+		t.addParents(filepath.Dir(c.Path))
+		if c.NewPath != "" {
+			t.addParents(filepath.Dir(c.NewPath))
+		}
+	}
+	paths := []string{}
+	for p := range t.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	retval := []*file.FileInfo{}
+	for _, p := range paths {
+		retval = append(retval, t.files[p])
+	}
+	return retval
+}
+
+// Expressions returns the files of the recording (see Files) as path
+// expressions for stub.NewStub. Data which can not be expressed (ex: data
+// containing commas) and file modes are left out.
+func (r *Recording) Expressions() []string {
+	children := map[string][]*file.FileInfo{}
+	dirs := []string{}
+	for _, fi := range r.Files() {
+		if fi.Path == "/" {
+			continue
+		}
+		if fi.FIsDir {
+			dirs = append(dirs, fi.Path)
+		}
+		dir := filepath.Dir(fi.Path)
+		children[dir] = append(children[dir], fi)
+	}
+	retval := []string{}
+	for _, dir := range dirs {
+		tags := []string{}
+		hasDir := false
+		for _, fi := range children[dir] {
+			if fi.FIsDir {
+				hasDir = true
+				continue
+			}
+			tags = append(tags, fi.FName+fileTags(fi))
+		}
+		switch {
+		case len(tags) > 0:
+			retval = append(retval, fmt.Sprintf("%s[%s]", dir, strings.Join(tags, ", ")))
+		case !hasDir:
+			retval = append(retval, dir)
+		}
+	}
+	for _, fi := range children["/"] {
+		if !fi.FIsDir {
+			retval = append(retval, "/"+fi.FName+fileTags(fi))
+		}
+	}
+	return retval
+}
+
+// fileTags returns the tags of fi in a path expression.
+func fileTags(fi *file.FileInfo) string {
+	tags := []string{}
+	if fi.Error != nil {
+		tags = append(tags, "err="+encodeErr(fi.Error))
+	}
+	if inlineData(fi.Data) {
+		tags = append(tags, "data="+string(fi.Data))
+	}
+	if !fi.FModTime.IsZero() {
+		tags = append(tags, "mtime="+fi.FModTime.UTC().Format(time.RFC3339))
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(tags, ", ") + ")"
+}
+
+// inlineData returns true if data can be a tag of a path expression. It must
+// not contain syntax of the parser nor be trimmed by it.
+func inlineData(data []byte) bool {
+	s := string(data)
+	return s != "" && strings.TrimSpace(s) == s &&
+		!strings.ContainsAny(s, string(os.PathSeparator)+",()[]|\n")
+}
+
+// tree collects the files revealed by calls.
+type tree struct {
+	files map[string]*file.FileInfo
+	// absent holds paths which did not exist
+	absent map[string]bool
+	// changed holds paths, and trees below dirs, changed by calls
+	changed     map[string]bool
+	changedDirs []string
+}
+
+func newTree() *tree {
+	return &tree{files: map[string]*file.FileInfo{}, absent: map[string]bool{}, changed: map[string]bool{}}
+}
+
+// changes holds the operations which change the file at the path of a call.
+var changes = map[string]bool{
+	"WriteFile": true, "Truncate": true, "Chmod": true, "Mkdir": true, "MkdirAll": true,
+	"Remove": true, "File.Write": true,
+}
+
+func (t *tree) add(c Call) {
+	err := decodeErr(c.Err)
+	switch c.Op {
+	case "Stat":
+		t.observe(c.Path, c.Info, nil, err)
+	case "ReadFile":
+		t.observe(c.Path, nil, c.Data, err)
+	case "ReadDir":
+		t.observe(c.Path, &Info{Name: filepath.Base(c.Path), Mode: os.ModeDir | 0755, IsDir: true}, nil, err)
+		for _, e := range c.Entries {
+			e := e
+			t.observe(filepath.Join(c.Path, e.Name), &e, nil, nil)
+		}
+	case "Walk":
+		for _, e := range c.Entries {
+			e := e
+			t.observe(e.Path, &e, nil, nil)
+		}
+	case "Open", "Create", "OpenFile":
+		if c.Info != nil || err != nil {
+			t.observe(c.Path, c.Info, c.Data, err)
+		}
+		if c.Flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+			t.change(c.Path, false)
+		}
+	case "Rename":
+		t.change(c.Path, true)
+		t.change(c.NewPath, true)
+	case "RemoveAll":
+		t.change(c.Path, true)
+	case "Chdir":
+		if err == nil {
+			t.observe(c.Path, &Info{Name: filepath.Base(c.Path), Mode: os.ModeDir | 0755, IsDir: true}, nil, nil)
+		}
+	default:
+		if changes[c.Op] {
+			t.change(c.Path, false)
+		}
+	}
+}
+
+func (t *tree) change(path string, recursive bool) {
+	t.changed[path] = true
+	if recursive {
+		t.changedDirs = append(t.changedDirs, path)
+	}
+}
+
+func (t *tree) isChanged(path string) bool {
+	if t.changed[path] {
+		return true
+	}
+	for _, dir := range t.changedDirs {
+		if strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// observe adds the file at path unless it was changed before.
+func (t *tree) observe(path string, info *Info, data []byte, err error) {
+	if t.isChanged(path) || t.absent[path] {
+		return
+	}
+	if os.IsNotExist(err) {
+		if _, ok := t.files[path]; !ok {
+			t.absent[path] = true
+		}
+		return
+	}
+	fi, ok := t.files[path]
+	if !ok {
+		fi = &file.FileInfo{FName: filepath.Base(path), Path: path, FMode: 0644}
+		if info != nil {
+			fi = info.fileInfo(path)
+		}
+		if err != nil {
+			fi.Error = err
+		}
+		t.files[path] = fi
+	}
+	if data != nil && fi.Data == nil {
+		fi.Data = data
+		fi.FSize = int64(len(data))
+	}
+	if path == string(os.PathSeparator) {
+		fi.FIsDir = true
+	}
+}
+
+// addParents adds dir and its parents as directories if they are unknown.
+func (t *tree) addParents(dir string) {
+	for dir != "" && dir != string(os.PathSeparator) && dir != "." {
+		if _, ok := t.files[dir]; !ok && !t.absent[dir] {
+			t.files[dir] = &file.FileInfo{FName: filepath.Base(dir), Path: dir, FMode: os.ModeDir | 0755, FIsDir: true}
+		}
+		dir = filepath.Dir(dir)
+	}
+}
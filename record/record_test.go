@@ -0,0 +1,204 @@
+package record
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shebang-go/fsmocker"
+	"github.com/shebang-go/fsmocker/internal/faket"
+	"github.com/shebang-go/fsmocker/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ fsmocker.Stub = &Recorder{}
+
+// program uses fs like code under test and logs what it sees.
+func program(fs fsmocker.Stub, dir string) []string {
+	log := []string{}
+	logf := func(format string, args ...interface{}) { log = append(log, fmt.Sprintf(format, args...)) }
+	a := filepath.Join(dir, "a.txt")
+
+	fi, err := fs.Stat(a)
+	if err == nil {
+		logf("stat %s %d %v %v", fi.Name(), fi.Size(), fi.Mode(), fi.ModTime().UTC())
+	}
+	_, err = fs.Stat(filepath.Join(dir, "missing"))
+	logf("stat missing: %v", os.IsNotExist(err))
+	data, err := fs.ReadFile(a)
+	logf("read %q %v", data, err)
+	entries, err := fs.ReadDir(dir)
+	for _, e := range entries {
+		logf("entry %s %v", e.Name(), e.IsDir())
+	}
+	logf("readdir %v", err)
+	logf("write %v", fs.WriteFile(filepath.Join(dir, "c.txt"), []byte("new"), 0644))
+	f, err := fs.Open(a)
+	if err == nil {
+		buf := make([]byte, 3)
+		n, err := f.Read(buf)
+		logf("file.read %q %v", buf[:n], err)
+		logf("file.close %v", f.Close())
+	}
+	logf("rename %v", fs.Rename(filepath.Join(dir, "c.txt"), filepath.Join(dir, "d.txt")))
+	data, err = fs.ReadFile(filepath.Join(dir, "d.txt"))
+	logf("read %q %v", data, err)
+	logf("remove %v", fs.Remove(filepath.Join(dir, "sub")) != nil)
+	logf("chdir %v", fs.Chdir(dir))
+	data, err = fs.ReadFile("a.txt")
+	logf("read relative %q %v", data, err)
+	return log
+}
+
+func recordProgram(t *testing.T) (*Recording, []string, string) {
+	dir, err := ioutil.TempDir("", "record")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), nil, 0644))
+
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	r := NewRecorder()
+	log := program(r, dir)
+	assert.NoError(t, os.Chdir(cwd))
+	return r.Recording(), log, dir
+}
+
+func TestReplay(t *testing.T) {
+	rec, want, dir := recordProgram(t)
+	assert.Contains(t, want, `read "hello" <nil>`)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, rec.Save(buf))
+	loaded, err := Load(buf)
+	assert.NoError(t, err)
+
+	ft := &faket.T{}
+	st := Replay(ft, loaded)
+	assert.Equal(t, want, program(st, dir))
+	assert.Empty(t, ft.Errors())
+
+	_, err = st.ReadFile(filepath.Join(dir, "a.txt"))
+	assert.True(t, errors.Is(err, ErrNotRecorded), "got %v", err)
+	assert.Len(t, ft.Errors(), 1)
+}
+
+func TestReplay_readSizes(t *testing.T) {
+	rec := &Recording{Cwd: "/", Calls: []Call{
+		{Op: "Open", Path: "/d/a", Info: &Info{Name: "a", Size: 5, Mode: 0644}, Data: []byte("hello")},
+		{Op: "File.Read", Path: "/d/a", N: 2},
+		{Op: "File.Read", Path: "/d/a", N: 3},
+		{Op: "File.Read", Path: "/d/a", Err: "EOF"},
+	}}
+	st := Replay(t, rec)
+	f, err := st.Open("/d/a")
+	assert.NoError(t, err)
+	got := []string{}
+	buf := make([]byte, 5)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+		got = append(got, string(buf[:n]))
+	}
+	assert.Equal(t, []string{"he", "llo"}, got)
+}
+
+func TestRecording_Files(t *testing.T) {
+	rec, _, dir := recordProgram(t)
+	got := map[string]bool{}
+	for _, fi := range rec.Files() {
+		rel, err := filepath.Rel(dir, fi.Path)
+		if err == nil && rel != "." && rel[0] != '.' {
+			got[rel] = fi.FIsDir
+		}
+	}
+	// c.txt and d.txt were created by the program, sub/b.txt was not seen
+	assert.Equal(t, map[string]bool{"a.txt": false, "sub": true}, got)
+}
+
+func TestRecording_Expressions(t *testing.T) {
+	rec := &Recording{Calls: []Call{
+		{Op: "ReadFile", Path: "/data/a", Data: []byte("hello")},
+		{Op: "ReadFile", Path: "/data/b", Data: []byte("a,b")},
+		{Op: "Stat", Path: "/data/bad", Err: "EIO"},
+		{Op: "Stat", Path: "/data/missing", Err: "ENOENT"},
+		{Op: "ReadDir", Path: "/data/empty"},
+		{Op: "WriteFile", Path: "/out/new"},
+	}}
+	assert.Equal(t, []string{
+		"/data[a(data=hello), b, bad(err=EIO)]",
+		"/data/empty",
+		"/out",
+	}, rec.Expressions())
+}
+
+func TestRecording_Expressions_parse(t *testing.T) {
+	data := map[string]string{
+		"/data/a": "hello", "/data/b": "a,b", "/data/c": "http://x", "/data/d": " padded",
+		"/data/e": "x(y)", "/data/f": "v1|v2", "/data/g": "[x]", "/data/h": "k=v",
+	}
+	rec := &Recording{}
+	for p, d := range data {
+		rec.Calls = append(rec.Calls, Call{Op: "ReadFile", Path: p, Data: []byte(d)})
+	}
+	got := map[string]string{}
+	for _, expr := range rec.Expressions() {
+		for _, fi := range parser.Parse(expr) {
+			if !fi.FIsDir {
+				got[fi.Path] = string(fi.Data)
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"/data/a": "hello", "/data/b": "", "/data/c": "", "/data/d": "",
+		"/data/e": "", "/data/f": "", "/data/g": "", "/data/h": "k=v",
+	}, got)
+}
+
+func TestReplay_temp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "record")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	run := func(fs fsmocker.Stub) []string {
+		log := []string{}
+		f, err := fs.CreateTemp(dir, "tmp*")
+		assert.NoError(t, err)
+		_, err = f.Write([]byte("data"))
+		log = append(log, fmt.Sprintf("write %v close %v", err, f.Close()))
+		if fi, err := fs.Stat(f.Name()); err == nil {
+			log = append(log, fmt.Sprintf("stat %d", fi.Size()))
+		}
+		log = append(log, fmt.Sprintf("remove %v", fs.Remove(f.Name())))
+		sub, err := fs.MkdirTemp(dir, "sub*")
+		assert.NoError(t, err)
+		log = append(log, fmt.Sprintf("write %v", fs.WriteFile(filepath.Join(sub, "a"), nil, 0644)))
+		log = append(log, fmt.Sprintf("removeall %v", fs.RemoveAll(sub)))
+		return log
+	}
+	r := NewRecorder()
+	want := run(r)
+	ft := &faket.T{}
+	assert.Equal(t, want, run(Replay(ft, r.Recording())))
+	assert.Empty(t, ft.Errors())
+}
+
+func TestReplay_chdir(t *testing.T) {
+	rec := &Recording{Cwd: "/", Calls: []Call{{Op: "Chdir", Path: "/w"}}}
+	st := Replay(t, rec)
+	assert.NoError(t, st.Chdir("/w"))
+	cwd, err := st.Getwd()
+	assert.NoError(t, err)
+	assert.Equal(t, "/w", cwd)
+}
@@ -0,0 +1,286 @@
+package record
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/shebang-go/fsmocker/file"
+	"github.com/shebang-go/fsmocker/stub"
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Recorder has the methods of fsmocker.Stub, runs them on the real file
+// system and records the calls and their results.
+type Recorder struct {
+	mu  sync.Mutex
+	rec *Recording
+}
+
+// NewRecorder creates a new recorder.
+func NewRecorder() *Recorder {
+	cwd, _ := os.Getwd()
+	return &Recorder{rec: &Recording{Cwd: cwd, TempDir: os.TempDir()}}
+}
+
+// Recording returns a copy of the recording.
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Recording{Cwd: r.rec.Cwd, TempDir: r.rec.TempDir, Calls: append([]Call{}, r.rec.Calls...)}
+}
+
+func (r *Recorder) record(c Call, err error) {
+	c.Err = encodeErr(err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Calls = append(r.rec.Calls, c)
+}
+
+// observe records a call of a method of an opened file.
+func (r *Recorder) observe(c testdouble.Call) {
+	rc := Call{Op: c.Op, Path: c.Path}
+	if n, ok := c.Args["n"].(int); ok {
+		rc.N = n
+	}
+	r.record(rc, c.Err)
+}
+
+// abs returns the absolute path of name.
+func abs(name string) string {
+	if p, err := filepath.Abs(name); err == nil {
+		return p
+	}
+	return name
+}
+
+// Config returns nil, files of the real file system can not be configured.
+func (r *Recorder) Config(p string) file.Configer {
+	return nil
+}
+
+// Options ignores opts, they apply to stubs only.
+func (r *Recorder) Options(opts ...stub.Option) {}
+
+// Stat calls os.Stat.
+func (r *Recorder) Stat(path string) (os.FileInfo, error) {
+	fi, err := os.Stat(path)
+	c := Call{Op: "Stat", Path: abs(path)}
+	if err == nil {
+		c.Info = newInfo(fi)
+	}
+	r.record(c, err)
+	return fi, err
+}
+
+// ReadFile calls ioutil.ReadFile.
+func (r *Recorder) ReadFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	r.record(Call{Op: "ReadFile", Path: abs(path), Data: data}, err)
+	return data, err
+}
+
+// ReadDir calls ioutil.ReadDir.
+func (r *Recorder) ReadDir(path string) ([]os.FileInfo, error) {
+	entries, err := ioutil.ReadDir(path)
+	c := Call{Op: "ReadDir", Path: abs(path)}
+	for _, e := range entries {
+		c.Entries = append(c.Entries, *newInfo(e))
+	}
+	r.record(c, err)
+	return entries, err
+}
+
+// Walk calls filepath.Walk.
+func (r *Recorder) Walk(root string, walkFn filepath.WalkFunc) error {
+	c := Call{Op: "Walk", Path: abs(root)}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if info != nil {
+			e := newInfo(info)
+			e.Path = abs(path)
+			c.Entries = append(c.Entries, *e)
+		}
+		return walkFn(path, info, err)
+	})
+	r.record(c, err)
+	return err
+}
+
+// Glob calls filepath.Glob.
+func (r *Recorder) Glob(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	r.record(Call{Op: "Glob", Path: pattern, Matches: matches}, err)
+	return matches, err
+}
+
+// WriteFile calls ioutil.WriteFile.
+func (r *Recorder) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	err := ioutil.WriteFile(filename, data, perm)
+	r.record(Call{Op: "WriteFile", Path: abs(filename), Perm: perm, Size: int64(len(data))}, err)
+	return err
+}
+
+// Truncate calls os.Truncate.
+func (r *Recorder) Truncate(name string, size int64) error {
+	err := os.Truncate(name, size)
+	r.record(Call{Op: "Truncate", Path: abs(name), Size: size}, err)
+	return err
+}
+
+// Chmod calls os.Chmod.
+func (r *Recorder) Chmod(name string, mode os.FileMode) error {
+	err := os.Chmod(name, mode)
+	r.record(Call{Op: "Chmod", Path: abs(name), Perm: mode}, err)
+	return err
+}
+
+// Rename calls os.Rename.
+func (r *Recorder) Rename(oldpath, newpath string) error {
+	err := os.Rename(oldpath, newpath)
+	r.record(Call{Op: "Rename", Path: abs(oldpath), NewPath: abs(newpath)}, err)
+	return err
+}
+
+// Open calls os.Open.
+func (r *Recorder) Open(name string) (file.Handle, error) {
+	return r.open("Open", name, os.O_RDONLY, 0)
+}
+
+// Create calls os.Create.
+func (r *Recorder) Create(name string) (file.Handle, error) {
+	return r.open("Create", name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile calls os.OpenFile.
+func (r *Recorder) OpenFile(name string, flag int, perm os.FileMode) (file.Handle, error) {
+	return r.open("OpenFile", name, flag, perm)
+}
+
+// open opens a file and records the call as op. The info and content of
+// regular files which existed before are recorded, the content is read from
+// the opened file, which is rewound afterwards.
+func (r *Recorder) open(op string, name string, flag int, perm os.FileMode) (file.Handle, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	c := Call{Op: op, Path: abs(name), Flag: flag, Perm: perm}
+	if err != nil {
+		r.record(c, err)
+		return nil, err
+	}
+	if flag&(os.O_CREATE|os.O_TRUNC) == 0 {
+		if fi, err := f.Stat(); err == nil {
+			c.Info = newInfo(fi)
+			if fi.Mode().IsRegular() && flag&os.O_WRONLY == 0 {
+				c.Data = readAll(f)
+			}
+		}
+	}
+	r.record(c, nil)
+	return file.NewOsFile(f, name, r.observe), nil
+}
+
+// readAll returns the content of f and seeks back to the start. It returns
+// nil if f can not be read.
+func readAll(f *os.File) []byte {
+	data, err := ioutil.ReadAll(f)
+	if _, serr := f.Seek(0, io.SeekStart); err != nil || serr != nil {
+		return nil
+	}
+	return data
+}
+
+// Mkdir calls os.Mkdir.
+func (r *Recorder) Mkdir(name string, perm os.FileMode) error {
+	err := os.Mkdir(name, perm)
+	r.record(Call{Op: "Mkdir", Path: abs(name), Perm: perm}, err)
+	return err
+}
+
+// MkdirAll calls os.MkdirAll.
+func (r *Recorder) MkdirAll(name string, perm os.FileMode) error {
+	err := os.MkdirAll(name, perm)
+	r.record(Call{Op: "MkdirAll", Path: abs(name), Perm: perm}, err)
+	return err
+}
+
+// Remove calls os.Remove.
+func (r *Recorder) Remove(name string) error {
+	err := os.Remove(name)
+	r.record(Call{Op: "Remove", Path: abs(name)}, err)
+	return err
+}
+
+// RemoveAll calls os.RemoveAll.
+func (r *Recorder) RemoveAll(name string) error {
+	err := os.RemoveAll(name)
+	r.record(Call{Op: "RemoveAll", Path: abs(name)}, err)
+	return err
+}
+
+// TempDir calls os.TempDir.
+func (r *Recorder) TempDir() string {
+	return os.TempDir()
+}
+
+// CreateTemp calls ioutil.TempFile.
+func (r *Recorder) CreateTemp(dir, pattern string) (file.Handle, error) {
+	f, err := ioutil.TempFile(dir, pattern)
+	c := Call{Op: "CreateTemp", Path: abs(tempDir(dir))}
+	if err != nil {
+		r.record(c, err)
+		return nil, err
+	}
+	c.NewPath = abs(f.Name())
+	r.record(c, nil)
+	return file.NewOsFile(f, f.Name(), r.observe), nil
+}
+
+// MkdirTemp calls ioutil.TempDir.
+func (r *Recorder) MkdirTemp(dir, pattern string) (string, error) {
+	name, err := ioutil.TempDir(dir, pattern)
+	c := Call{Op: "MkdirTemp", Path: abs(tempDir(dir))}
+	if err != nil {
+		r.record(c, err)
+		return "", err
+	}
+	c.NewPath = abs(name)
+	r.record(c, nil)
+	return name, nil
+}
+
+// tempDir returns the directory temporary files are created in.
+func tempDir(dir string) string {
+	if dir == "" {
+		return os.TempDir()
+	}
+	return dir
+}
+
+// Abs calls filepath.Abs.
+func (r *Recorder) Abs(p string) (string, error) {
+	return filepath.Abs(p)
+}
+
+// Chdir calls os.Chdir.
+func (r *Recorder) Chdir(dir string) error {
+	c := Call{Op: "Chdir", Path: abs(dir)}
+	err := os.Chdir(dir)
+	r.record(c, err)
+	return err
+}
+
+// Getwd calls os.Getwd.
+func (r *Recorder) Getwd() (string, error) {
+	return os.Getwd()
+}
+
+// FileInfo returns the FileInfo of p or nil if it can not be stat'ed. The
+// call is not recorded.
+func (r *Recorder) FileInfo(p string) os.FileInfo {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil
+	}
+	return fi
+}
@@ -0,0 +1,128 @@
+package record
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shebang-go/fsmocker/file"
+	"github.com/shebang-go/fsmocker/stub"
+)
+
+// ErrNotRecorded is returned by a replaying stub for calls which are not in
+// the recording.
+var ErrNotRecorded = errors.New("call not recorded")
+
+// handles holds the operations which return a File. Their calls are run on
+// the stub even if the recorded result differs.
+var handles = map[string]bool{"Open": true, "Create": true, "OpenFile": true, "CreateTemp": true}
+
+// Replay creates a stub which returns the recorded results. The stub holds
+// the files revealed by the recording (see Recording.Files). Calls are
+// matched by operation and path in the order they were recorded, other
+// calls fail with ErrNotRecorded and fail t. Reads of opened files return
+// the recorded number of bytes. Temporary files get other names than in the
+// recording, calls on them match the calls on the recorded names.
+func Replay(t testing.TB, rec *Recording, opts ...stub.Option) *stub.Stub {
+	p := &player{t: t, calls: map[string][]Call{}, temp: map[string]string{}}
+	for _, c := range rec.Calls {
+		k := key(c.Op, c.Path)
+		p.calls[k] = append(p.calls[k], c)
+	}
+	opts = append([]stub.Option{
+		stub.WithWorkingDir(rec.Cwd),
+		stub.WithTempDir(rec.TempDir),
+		stub.WithFiles(rec.Files()...),
+	}, opts...)
+	return stub.NewStub(nil, append(opts, stub.WithInterceptors(p.intercept))...).(*stub.Stub)
+}
+
+type player struct {
+	t     testing.TB
+	mu    sync.Mutex
+	calls map[string][]Call
+	// temp maps the names of temporary files and directories to the names
+	// in the recording
+	temp map[string]string
+}
+
+func key(op string, path string) string {
+	return op + "\x00" + path
+}
+
+// next returns the next recorded call of op for path.
+func (p *player) next(op string, path string) (Call, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k := key(op, p.recorded(path))
+	calls := p.calls[k]
+	if len(calls) == 0 {
+		return Call{}, false
+	}
+	p.calls[k] = calls[1:]
+	return calls[0], true
+}
+
+// recorded returns the name of path in the recording.
+func (p *player) recorded(path string) string {
+	for name, rec := range p.temp {
+		if path == name {
+			return rec
+		}
+		rel, err := filepath.Rel(name, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+			return filepath.Join(rec, rel)
+		}
+	}
+	return path
+}
+
+// created maps the name of a created temporary file or directory to the
+// name in the recording.
+func (p *player) created(path string, rec string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.temp[path] = rec
+}
+
+func (p *player) intercept(req *file.Request, next file.Handler) *file.Response {
+	c, ok := p.next(req.Op, req.Path)
+	if !ok {
+		p.t.Errorf("fsmocker: %s %s was not recorded", req.Op, req.Path)
+		return &file.Response{Err: ErrNotRecorded}
+	}
+	if err := decodeErr(c.Err); err != nil {
+		return &file.Response{Err: err}
+	}
+	if req.Op == "File.Read" {
+		req.Args["len"] = c.N
+	}
+	resp := next(req)
+	if (req.Op == "CreateTemp" || req.Op == "MkdirTemp") && resp.Err == nil {
+		p.created(req.Path, c.NewPath)
+	}
+	if handles[req.Op] {
+		return resp
+	}
+	resp.Err = nil
+	switch req.Op {
+	case "Stat":
+		if c.Info != nil {
+			resp.Result = c.Info.fileInfo(req.Path)
+		}
+	case "ReadFile":
+		resp.Result = append([]byte{}, c.Data...)
+	case "ReadDir":
+		entries := []os.FileInfo{}
+		for _, e := range c.Entries {
+			entries = append(entries, e.fileInfo(filepath.Join(req.Path, e.Name)))
+		}
+		resp.Result = entries
+	case "Glob":
+		resp.Result = c.Matches
+	}
+	return resp
+}
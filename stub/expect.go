@@ -0,0 +1,360 @@
+package stub
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shebang-go/fsmocker/file"
+	"github.com/shebang-go/fsmocker/matchers"
+	"github.com/shebang-go/fsmocker/testdouble"
+)
+
+// Expecter creates expectations for calls of a stub (see Stub.Expect).
+type Expecter struct {
+	st *Stub
+}
+
+// Expectation is an expected call of a stub. By default a call is expected
+// exactly once and the result is taken from the stub (see Return).
+type Expectation struct {
+	op       string
+	path     string
+	matchers []matchers.Matcher
+	min      int
+	max      int
+	calls    int
+	ret      []interface{}
+	prereqs  []*Expectation
+}
+
+// expectations holds the expectations of a stub.
+type expectations struct {
+	mu       sync.Mutex
+	list     []*Expectation
+	failures []string
+	// verified holds the tests which call AssertExpectations at cleanup
+	verified map[testing.TB]bool
+}
+
+// Expect returns an Expecter to create expectations. AssertExpectations(t)
+// is called at the end of the test t.
+func (st *Stub) Expect(t testing.TB) *Expecter {
+	if st.mock == nil {
+		st.mock = &expectations{verified: map[testing.TB]bool{}}
+	}
+	st.mock.mu.Lock()
+	defer st.mock.mu.Unlock()
+	if !st.mock.verified[t] {
+		st.mock.verified[t] = true
+		t.Cleanup(func() { st.AssertExpectations(t) })
+	}
+	return &Expecter{st: st}
+}
+
+// Call expects a call of op for path.
+func (e *Expecter) Call(op string, path string) *Expectation {
+	exp := &Expectation{op: op, path: e.st.fs.Resolve(path), min: 1, max: 1}
+	e.st.mock.mu.Lock()
+	defer e.st.mock.mu.Unlock()
+	e.st.mock.list = append(e.st.mock.list, exp)
+	return exp
+}
+
+// Stat expects a call of Stat. Return takes (os.FileInfo, error).
+func (e *Expecter) Stat(path string) *Expectation { return e.Call("Stat", path) }
+
+// ReadFile expects a call of ReadFile. Return takes ([]byte or string, error).
+func (e *Expecter) ReadFile(path string) *Expectation { return e.Call("ReadFile", path) }
+
+// ReadDir expects a call of ReadDir. Return takes ([]os.FileInfo, error).
+func (e *Expecter) ReadDir(path string) *Expectation { return e.Call("ReadDir", path) }
+
+// Walk expects a call of Walk. Return takes (error).
+func (e *Expecter) Walk(root string) *Expectation { return e.Call("Walk", root) }
+
+// WriteFile expects a call of WriteFile. With matches (data, perm), Return
+// takes (error).
+func (e *Expecter) WriteFile(path string) *Expectation { return e.Call("WriteFile", path) }
+
+// Open expects a call of Open. Return takes (file.Handle, error).
+func (e *Expecter) Open(path string) *Expectation { return e.Call("Open", path) }
+
+// Create expects a call of Create. Return takes (file.Handle, error).
+func (e *Expecter) Create(path string) *Expectation { return e.Call("Create", path) }
+
+// OpenFile expects a call of OpenFile. With matches (flag, perm), Return
+// takes (file.Handle, error).
+func (e *Expecter) OpenFile(path string) *Expectation { return e.Call("OpenFile", path) }
+
+// Truncate expects a call of Truncate. With matches (size), Return takes
+// (error).
+func (e *Expecter) Truncate(path string) *Expectation { return e.Call("Truncate", path) }
+
+// Chmod expects a call of Chmod. With matches (mode), Return takes (error).
+func (e *Expecter) Chmod(path string) *Expectation { return e.Call("Chmod", path) }
+
+// Rename expects a call of Rename. With matches (newpath), Return takes
+// (error).
+func (e *Expecter) Rename(oldpath string) *Expectation { return e.Call("Rename", oldpath) }
+
+// Mkdir expects a call of Mkdir. With matches (perm), Return takes (error).
+func (e *Expecter) Mkdir(path string) *Expectation { return e.Call("Mkdir", path) }
+
+// MkdirAll expects a call of MkdirAll. With matches (perm), Return takes
+// (error).
+func (e *Expecter) MkdirAll(path string) *Expectation { return e.Call("MkdirAll", path) }
+
+// Remove expects a call of Remove. Return takes (error).
+func (e *Expecter) Remove(path string) *Expectation { return e.Call("Remove", path) }
+
+// RemoveAll expects a call of RemoveAll. Return takes (error).
+func (e *Expecter) RemoveAll(path string) *Expectation { return e.Call("RemoveAll", path) }
+
+// With sets matchers for the arguments of the call following the path.
+func (exp *Expectation) With(m ...matchers.Matcher) *Expectation {
+	exp.matchers = m
+	return exp
+}
+
+// Times expects exactly n calls.
+func (exp *Expectation) Times(n int) *Expectation {
+	exp.min, exp.max = n, n
+	return exp
+}
+
+// AtLeast expects n or more calls.
+func (exp *Expectation) AtLeast(n int) *Expectation {
+	exp.min, exp.max = n, -1
+	return exp
+}
+
+// AtMost expects n or less calls.
+func (exp *Expectation) AtMost(n int) *Expectation {
+	exp.min, exp.max = 0, n
+	return exp
+}
+
+// AnyTimes allows any number of calls.
+func (exp *Expectation) AnyTimes() *Expectation {
+	return exp.AtLeast(0)
+}
+
+// Never expects no call.
+func (exp *Expectation) Never() *Expectation {
+	return exp.Times(0)
+}
+
+// Return sets the values returned by the call instead of the result of the
+// stub. The values pass through the interceptors of the stub, so hooks and
+// fault rules apply to them.
+func (exp *Expectation) Return(values ...interface{}) *Expectation {
+	exp.ret = values
+	if exp.ret == nil {
+		exp.ret = []interface{}{}
+	}
+	return exp
+}
+
+// String describes the expected call.
+func (exp *Expectation) String() string {
+	if len(exp.matchers) == 0 {
+		return fmt.Sprintf("%s(%s)", exp.op, exp.path)
+	}
+	args := []string{exp.path}
+	for _, m := range exp.matchers {
+		args = append(args, m.String())
+	}
+	return fmt.Sprintf("%s(%s)", exp.op, strings.Join(args, ", "))
+}
+
+func (exp *Expectation) matches(op string, path string, args []interface{}) bool {
+	if exp.op != op || exp.path != path {
+		return false
+	}
+	for i, m := range exp.matchers {
+		if i >= len(args) || !m.Matches(args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (exp *Expectation) satisfied() bool {
+	return exp.calls >= exp.min
+}
+
+func (exp *Expectation) exhausted() bool {
+	return exp.max >= 0 && exp.calls >= exp.max
+}
+
+// InOrder expects the calls in the given order.
+func InOrder(exps ...*Expectation) {
+	for i := 1; i < len(exps); i++ {
+		exps[i].prereqs = append(exps[i].prereqs, exps[i-1])
+	}
+}
+
+// AssertExpectations reports unsatisfied expectations and unexpected calls.
+// It returns true if all expectations are met.
+func (st *Stub) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+	if st.mock == nil {
+		return true
+	}
+	st.mock.mu.Lock()
+	defer st.mock.mu.Unlock()
+	ok := true
+	for _, f := range st.mock.failures {
+		t.Errorf("fsmocker: %s", f)
+		ok = false
+	}
+	for _, exp := range st.mock.list {
+		if !exp.satisfied() {
+			t.Errorf("fsmocker: missing call %s: expected %s, got %d", exp, describeCount(exp), exp.calls)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func describeCount(exp *Expectation) string {
+	switch {
+	case exp.max < 0:
+		return fmt.Sprintf("at least %d call(s)", exp.min)
+	case exp.min == exp.max:
+		return fmt.Sprintf("%d call(s)", exp.min)
+	}
+	return fmt.Sprintf("at most %d call(s)", exp.max)
+}
+
+// mockResult is the result of a mocked call (see Expectation.Return).
+type mockResult struct {
+	ret []interface{}
+	err error
+}
+
+// expected checks a call against the expectations. If the matching
+// expectation has values (see Return) it runs the call with these values
+// through the interceptors of the FS and returns the result.
+func (st *Stub) expected(op string, name string, args ...interface{}) (mockResult, bool) {
+	ret, ok := st.match(op, name, args)
+	if !ok {
+		return mockResult{}, false
+	}
+	req := &file.Request{Op: op, Path: st.fs.Resolve(name), Args: testdouble.Args{"mocked": true}}
+	resp := st.fs.Run(req, func(req *file.Request) *file.Response {
+		values := append([]interface{}{}, ret...)
+		if req.Transform != nil {
+			for i := range values {
+				if data := retBytes(values, i); data != nil {
+					values[i] = req.Transform(append([]byte{}, data...))
+				}
+			}
+		}
+		var err error
+		if len(values) > 0 {
+			err = retError(values, len(values)-1)
+		}
+		return &file.Response{Result: values, Err: err}
+	})
+	values, _ := resp.Result.([]interface{})
+	return mockResult{ret: values, err: resp.Err}, true
+}
+
+// match checks a call against the expectations. It returns the values of
+// Return if the matching expectation has some.
+func (st *Stub) match(op string, name string, args []interface{}) ([]interface{}, bool) {
+	if st.mock == nil {
+		return nil, false
+	}
+	st.mock.mu.Lock()
+	defer st.mock.mu.Unlock()
+
+	path := st.fs.Resolve(name)
+	var found, known *Expectation
+	for _, exp := range st.mock.list {
+		if exp.op != op || exp.path != path {
+			continue
+		}
+		known = exp
+		if exp.matches(op, path, args) {
+			if found == nil {
+				found = exp
+			}
+			if !exp.exhausted() {
+				found = exp
+				break
+			}
+		}
+	}
+	switch {
+	case found == nil && known == nil:
+		return nil, false
+	case found == nil:
+		st.mock.failures = append(st.mock.failures, fmt.Sprintf("unexpected call %s(%s) with %v", op, path, args))
+		return nil, false
+	case found.exhausted():
+		st.mock.failures = append(st.mock.failures, fmt.Sprintf("unexpected call %s: expected %s, got %d", found, describeCount(found), found.calls+1))
+	}
+	for _, pre := range found.prereqs {
+		if !pre.satisfied() {
+			st.mock.failures = append(st.mock.failures, fmt.Sprintf("call %s made before %s", found, pre))
+		}
+	}
+	found.calls++
+	if found.ret == nil {
+		return nil, false
+	}
+	return found.ret, true
+}
+
+func retError(ret []interface{}, i int) error {
+	if i < len(ret) {
+		if err, ok := ret[i].(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func retBytes(ret []interface{}, i int) []byte {
+	if i < len(ret) {
+		switch v := ret[i].(type) {
+		case []byte:
+			return v
+		case string:
+			return []byte(v)
+		}
+	}
+	return nil
+}
+
+func retFileInfo(ret []interface{}, i int) os.FileInfo {
+	if i < len(ret) {
+		if v, ok := ret[i].(os.FileInfo); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func retFileInfos(ret []interface{}, i int) []os.FileInfo {
+	if i < len(ret) {
+		if v, ok := ret[i].([]os.FileInfo); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func retFile(ret []interface{}, i int) file.Handle {
+	if i < len(ret) {
+		if v, ok := ret[i].(file.Handle); ok {
+			return v
+		}
+	}
+	return nil
+}
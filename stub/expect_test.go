@@ -0,0 +1,236 @@
+package stub
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/shebang-go/fsmocker/file"
+	"github.com/shebang-go/fsmocker/internal/faket"
+	"github.com/shebang-go/fsmocker/matchers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStub_Expect(t *testing.T) {
+	errRead := errors.New("read")
+	tests := []struct {
+		name       string
+		expect     func(st *Stub, t testing.TB)
+		run        func(t *testing.T, st *Stub)
+		wantErrors int
+	}{
+		{
+			name: "returnValues",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).ReadFile("/etc/app.yaml").Times(1).Return([]byte("mocked"), nil)
+			},
+			run: func(t *testing.T, st *Stub) {
+				data, err := st.ReadFile("/etc/app.yaml")
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("mocked"), data)
+			},
+		},
+		{
+			name: "returnError",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).Stat("/etc/app.yaml").Return(nil, errRead)
+			},
+			run: func(t *testing.T, st *Stub) {
+				_, err := st.Stat("/etc/app.yaml")
+				assert.Equal(t, errRead, err)
+			},
+		},
+		{
+			name: "passThrough",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).ReadFile("/etc/app.yaml")
+			},
+			run: func(t *testing.T, st *Stub) {
+				data, err := st.ReadFile("/etc/app.yaml")
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("stubbed"), data)
+			},
+		},
+		{
+			name: "relativePath",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).ReadFile("/etc/app.yaml")
+			},
+			run: func(t *testing.T, st *Stub) {
+				assert.NoError(t, st.Chdir("/etc"))
+				st.ReadFile("app.yaml")
+			},
+		},
+		{
+			name: "withMatcher",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).WriteFile("/out").With(matchers.Contains("ok"))
+			},
+			run: func(t *testing.T, st *Stub) {
+				assert.NoError(t, st.WriteFile("/out", []byte("status: ok"), 0644))
+			},
+		},
+		{
+			name: "withMatcherMismatch",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).WriteFile("/out").With(matchers.Contains("ok"), matchers.Eq(os.FileMode(0600)))
+			},
+			run: func(t *testing.T, st *Stub) {
+				st.WriteFile("/out", []byte("status: ok"), 0644)
+			},
+			wantErrors: 2,
+		},
+		{
+			name: "missingCall",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).ReadFile("/etc/app.yaml")
+			},
+			run:        func(t *testing.T, st *Stub) {},
+			wantErrors: 1,
+		},
+		{
+			name: "tooManyCalls",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).ReadFile("/etc/app.yaml").Times(2)
+			},
+			run: func(t *testing.T, st *Stub) {
+				for i := 0; i < 3; i++ {
+					st.ReadFile("/etc/app.yaml")
+				}
+			},
+			wantErrors: 1,
+		},
+		{
+			name: "atLeast",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).ReadFile("/etc/app.yaml").AtLeast(2)
+			},
+			run: func(t *testing.T, st *Stub) {
+				for i := 0; i < 5; i++ {
+					st.ReadFile("/etc/app.yaml")
+				}
+			},
+		},
+		{
+			name: "never",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).Remove("/etc/app.yaml").Never()
+			},
+			run: func(t *testing.T, st *Stub) {
+				st.Remove("/etc/app.yaml")
+			},
+			wantErrors: 1,
+		},
+		{
+			name: "unexpectedPathsAreIgnored",
+			expect: func(st *Stub, t testing.TB) {
+				st.Expect(t).ReadFile("/etc/app.yaml").AnyTimes()
+			},
+			run: func(t *testing.T, st *Stub) {
+				st.ReadFile("/etc/other")
+			},
+		},
+		{
+			name: "inOrder",
+			expect: func(st *Stub, t testing.TB) {
+				InOrder(
+					st.Expect(t).Stat("/etc/app.yaml"),
+					st.Expect(t).ReadFile("/etc/app.yaml"),
+				)
+			},
+			run: func(t *testing.T, st *Stub) {
+				st.Stat("/etc/app.yaml")
+				st.ReadFile("/etc/app.yaml")
+			},
+		},
+		{
+			name: "inOrderViolated",
+			expect: func(st *Stub, t testing.TB) {
+				InOrder(
+					st.Expect(t).Stat("/etc/app.yaml"),
+					st.Expect(t).ReadFile("/etc/app.yaml"),
+				)
+			},
+			run: func(t *testing.T, st *Stub) {
+				st.ReadFile("/etc/app.yaml")
+				st.Stat("/etc/app.yaml")
+			},
+			wantErrors: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := NewStub([]string{"/etc[app.yaml(data=stubbed)]"}).(*Stub)
+			ft := &faket.T{}
+			tt.expect(st, ft)
+			tt.run(t, st)
+			assert.Equal(t, tt.wantErrors == 0, st.AssertExpectations(&faket.T{}))
+			ft.RunCleanups()
+			assert.Len(t, ft.Errors(), tt.wantErrors, "%v", ft.Errors())
+		})
+	}
+}
+
+func TestStub_Expect_cleanup(t *testing.T) {
+	ft := &faket.T{}
+	st := NewStub([]string{"/etc[app.yaml(data=stubbed)]"}).(*Stub)
+	st.Expect(ft).ReadFile("/etc/app.yaml").Times(2)
+	st.Expect(ft).Stat("/etc/app.yaml").Never()
+	st.ReadFile("/etc/app.yaml")
+	assert.Empty(t, ft.Errors())
+	ft.RunCleanups()
+	assert.Equal(t, []string{"fsmocker: missing call ReadFile(/etc/app.yaml): expected 2 call(s), got 1"}, ft.Errors())
+}
+
+func TestStub_Expect_spy(t *testing.T) {
+	st := NewStub([]string{"/etc[app.yaml(data=stubbed)]"}).(*Stub)
+	st.Expect(t).ReadFile("/etc/app.yaml").Return("mocked", nil)
+	st.ReadFile("/etc/app.yaml")
+	assert.Equal(t, 1, st.CallCount("ReadFile", "/etc/app.yaml"))
+}
+
+func TestStub_Expect_interceptors(t *testing.T) {
+	errHook := errors.New("hook")
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantData []byte
+		wantErr  error
+	}{
+		{
+			name:     "mocked",
+			wantData: []byte("mocked"),
+		},
+		{
+			name: "onBefore",
+			opts: []Option{OnBefore("ReadFile", "", func(oc *file.OpContext) error {
+				return errHook
+			})},
+			wantErr: errHook,
+		},
+		{
+			name: "onAfter",
+			opts: []Option{OnAfter("ReadFile", "", func(oc *file.OpContext) error {
+				assert.Equal(t, true, oc.Args["mocked"])
+				return nil
+			})},
+			wantData: []byte("mocked"),
+		},
+		{
+			name:    "rule",
+			opts:    []Option{WithRules(file.NewRule("eio").Op("ReadFile").Error(syscall.EIO))},
+			wantErr: syscall.EIO,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := NewStub([]string{"/etc[app.yaml(data=stubbed)]"}, tt.opts...).(*Stub)
+			st.Expect(t).ReadFile("/etc/app.yaml").Return("mocked", nil)
+			data, err := st.ReadFile("/etc/app.yaml")
+			assert.Equal(t, tt.wantData, data)
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			assert.Equal(t, 1, st.CallCount("ReadFile", "/etc/app.yaml"))
+		})
+	}
+}
@@ -3,9 +3,10 @@
 package stub
 
 import (
-	"fmt"
+	"context"
 	"os"
 	"path/filepath"
+	"testing"
 
 	"github.com/shebang-go/fsmocker/file"
 	"github.com/shebang-go/fsmocker/parser"
@@ -21,8 +22,24 @@ type Stuber interface {
 	Stat(path string) (os.FileInfo, error)
 	ReadDir(path string) ([]os.FileInfo, error)
 	Walk(root string, walkFn filepath.WalkFunc) error
+	Glob(pattern string) ([]string, error)
 	WriteFile(filename string, data []byte, perm os.FileMode) error
+	Truncate(name string, size int64) error
+	Chmod(name string, mode os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Open(name string) (file.Handle, error)
+	Create(name string) (file.Handle, error)
+	OpenFile(name string, flag int, perm os.FileMode) (file.Handle, error)
+	Mkdir(name string, perm os.FileMode) error
+	MkdirAll(name string, perm os.FileMode) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	TempDir() string
+	CreateTemp(dir, pattern string) (file.Handle, error)
+	MkdirTemp(dir, pattern string) (string, error)
 	Abs(p string) (string, error)
+	Chdir(dir string) error
+	Getwd() (string, error)
 	FileInfo(p string) os.FileInfo
 }
 
@@ -31,6 +48,8 @@ type Stub struct {
 	testDouble testdouble.TestDouble
 	// fs is the test file system.
 	fs *file.FS
+	// mock holds expectations (see Expect)
+	mock *expectations
 }
 
 var (
@@ -46,6 +65,128 @@ func WithGlobalOptions(opts ...testdouble.Option) Option {
 	}
 }
 
+// WithT is an option to set the test of the stub. It is used to verify
+// expectations at the end of the test (see Expect).
+func WithT(t *testing.T) Option {
+	return WithGlobalOptions(testdouble.WithT(t))
+}
+
+// WithFiles is an option to add files to the stub.
+func WithFiles(files ...*file.FileInfo) Option {
+	return func(stub *Stub) {
+		stub.fs.AddFiles(files)
+	}
+}
+
+// WithWorkingDir is an option to set the working directory relative paths
+// are resolved against.
+func WithWorkingDir(dir string) Option {
+	return func(stub *Stub) {
+		stub.fs.AbsPathPrefix = dir
+	}
+}
+
+// WithClock is an option to set the clock used for file timestamps.
+func WithClock(c file.Clock) Option {
+	return func(stub *Stub) {
+		stub.fs.SetClock(c)
+	}
+}
+
+// WithTempDir is an option to set the directory returned by TempDir.
+func WithTempDir(dir string) Option {
+	return func(stub *Stub) {
+		stub.fs.SetTempDir(dir)
+	}
+}
+
+// WithTempSeed is an option to seed the generator of temp file names.
+func WithTempSeed(seed int64) Option {
+	return func(stub *Stub) {
+		stub.fs.SetTempSeed(seed)
+	}
+}
+
+// WithDoublestar is an option to enable ** in Glob patterns.
+func WithDoublestar() Option {
+	return func(stub *Stub) {
+		stub.fs.SetDoublestar(true)
+	}
+}
+
+// WithDirOrder is an option to set the order of entries returned by
+// File.Readdir (ex: file.Shuffle(seed)).
+func WithDirOrder(o file.DirOrder) Option {
+	return func(stub *Stub) {
+		stub.fs.SetDirOrder(o)
+	}
+}
+
+// WithStrict is an option to fail the test t on every access to a path which
+// neither was declared nor created. Paths matching one of the allow patterns
+// (ex: /var/run/*.pid, /etc/**) may be missing.
+func WithStrict(t testing.TB, allow ...string) Option {
+	return func(stub *Stub) {
+		stub.fs.SetStrict(t, allow...)
+	}
+}
+
+// WithCoverage is an option to report declared paths which were never
+// stat'ed, read, listed or walked at the end of the test t. With
+// file.CoverageFail the test fails if there are unused paths.
+func WithCoverage(t testing.TB, mode file.CoverageMode) Option {
+	return func(stub *Stub) {
+		stub.fs.TrackCoverage(t, mode)
+	}
+}
+
+// WithRules is an option to add fault injection rules (see file.Rule).
+func WithRules(rules ...*file.Rule) Option {
+	return func(stub *Stub) {
+		stub.fs.AddRule(rules...)
+	}
+}
+
+// WithDurability is an option to track which changes are durable, so a
+// crash can be simulated (see file.FS.TrackDurability).
+func WithDurability(pageSize int) Option {
+	return func(stub *Stub) {
+		stub.fs.TrackDurability(pageSize)
+	}
+}
+
+// WithQuota is an option to limit the capacity of the stub (see
+// file.Quota).
+func WithQuota(q file.Quota) Option {
+	return func(stub *Stub) {
+		stub.fs.SetQuota(q)
+	}
+}
+
+// OnBefore is an option to register a hook called before each operation op
+// on paths matching pattern (see file.FS.OnBefore).
+func OnBefore(op string, pattern string, fn file.HookFunc) Option {
+	return func(stub *Stub) {
+		stub.fs.OnBefore(op, pattern, fn)
+	}
+}
+
+// OnAfter is an option to register a hook called after each operation op on
+// paths matching pattern (see file.FS.OnAfter).
+func OnAfter(op string, pattern string, fn file.HookFunc) Option {
+	return func(stub *Stub) {
+		stub.fs.OnAfter(op, pattern, fn)
+	}
+}
+
+// WithInterceptors is an option to add interceptors to the operations of
+// the stub (see file.FS.Use).
+func WithInterceptors(interceptors ...file.Interceptor) Option {
+	return func(stub *Stub) {
+		stub.fs.Use(interceptors...)
+	}
+}
+
 // NewStub creates a new stub.
 func NewStub(paths []string, opts ...Option) Stuber {
 
@@ -58,7 +199,6 @@ func NewStub(paths []string, opts ...Option) Stuber {
 	}
 
 	for _, opt := range opts {
-		fmt.Println(">>>>>>>>>>>>>>>>>>>>", opt)
 		opt(stub)
 	}
 	return stub
@@ -76,6 +216,88 @@ func (st *Stub) TestDouble(v ...testdouble.TestDoubler) testdouble.TestDoubler {
 	return &st.testDouble
 }
 
+// Calls returns all calls made to the stub.
+func (st *Stub) Calls() []testdouble.Call {
+	return st.testDouble.Calls()
+}
+
+// CallsTo returns all calls of op made to the stub.
+func (st *Stub) CallsTo(op string) []testdouble.Call {
+	return st.testDouble.CallsTo(op)
+}
+
+// CallCount returns the number of calls of op for path. An empty op or path
+// matches any op or path.
+func (st *Stub) CallCount(op string, path string) int {
+	return st.testDouble.CallCount(op, path)
+}
+
+// Trace renders the calls made to the stub like strace (see
+// testdouble.FormatCall).
+func (st *Stub) Trace() string {
+	return st.testDouble.Trace()
+}
+
+// Coverage returns the access coverage of the declared paths (see
+// WithCoverage).
+func (st *Stub) Coverage() file.Coverage {
+	return st.fs.Coverage()
+}
+
+// Statfs is a stub for syscall.Statfs
+func (st *Stub) Statfs(name string) (file.DiskUsage, error) {
+	return st.fs.Statfs(name)
+}
+
+// SetQuota limits the capacity of the stub (see file.Quota).
+func (st *Stub) SetQuota(q file.Quota) {
+	st.fs.SetQuota(q)
+}
+
+// Sync makes all changes durable (see WithDurability).
+func (st *Stub) Sync() {
+	st.fs.Sync()
+}
+
+// Crash simulates a power loss (see WithDurability).
+func (st *Stub) Crash() {
+	st.fs.Crash()
+}
+
+// CrashRandom simulates a power loss with a random durable state (see
+// WithDurability).
+func (st *Stub) CrashRandom(seed int64) {
+	st.fs.CrashRandom(seed)
+}
+
+// AddRule adds fault injection rules (see file.Rule).
+func (st *Stub) AddRule(rules ...*file.Rule) {
+	st.fs.AddRule(rules...)
+}
+
+// RemoveRule removes the fault injection rules named name.
+func (st *Stub) RemoveRule(name string) {
+	st.fs.RemoveRule(name)
+}
+
+// Rule returns the fault injection rule named name or nil.
+func (st *Stub) Rule(name string) *file.Rule {
+	return st.fs.Rule(name)
+}
+
+// Use adds interceptors to the operations of the stub (see file.FS.Use).
+func (st *Stub) Use(interceptors ...file.Interceptor) {
+	st.fs.Use(interceptors...)
+}
+
+// handle returns f as a Handle, a nil f is returned as nil.
+func handle(f *file.File, err error) (file.Handle, error) {
+	if f == nil {
+		return nil, err
+	}
+	return f, err
+}
+
 // Config provides access to stubs
 func (st *Stub) Config(p string) file.Configer {
 	return st.fs.Config(p)
@@ -94,30 +316,173 @@ func (st *Stub) FileInfo(path string) os.FileInfo {
 
 // Stat is a stub for os.Stat
 func (st *Stub) Stat(path string) (os.FileInfo, error) {
+	if m, ok := st.expected("Stat", path); ok {
+		return retFileInfo(m.ret, 0), m.err
+	}
 	return st.fs.Stat(path)
 }
 
+// StatContext is like Stat, but returns ctx.Err() if ctx is done before the
+// delays of the file elapsed.
+func (st *Stub) StatContext(ctx context.Context, path string) (os.FileInfo, error) {
+	if m, ok := st.expected("Stat", path); ok {
+		return retFileInfo(m.ret, 0), m.err
+	}
+	return st.fs.StatContext(ctx, path)
+}
+
 // ReadFile is a stub for ioutil.ReadFile
 func (st *Stub) ReadFile(path string) ([]byte, error) {
+	if m, ok := st.expected("ReadFile", path); ok {
+		return retBytes(m.ret, 0), m.err
+	}
 	return st.fs.ReadFile(path)
 }
 
+// ReadFileContext is like ReadFile, but returns ctx.Err() if ctx is done
+// before the delays of the file elapsed.
+func (st *Stub) ReadFileContext(ctx context.Context, path string) ([]byte, error) {
+	if m, ok := st.expected("ReadFile", path); ok {
+		return retBytes(m.ret, 0), m.err
+	}
+	return st.fs.ReadFileContext(ctx, path)
+}
+
 // ReadDir is a stub for ioutil.ReadDir
 func (st *Stub) ReadDir(path string) ([]os.FileInfo, error) {
+	if m, ok := st.expected("ReadDir", path); ok {
+		return retFileInfos(m.ret, 0), m.err
+	}
 	return st.fs.ReadDir(path)
 }
 
 // Walk is a stub for filepath.Walk
 func (st *Stub) Walk(root string, walkFn filepath.WalkFunc) error {
+	if m, ok := st.expected("Walk", root); ok {
+		return m.err
+	}
 	return st.fs.Walk(root, walkFn)
 }
 
+// Glob is a stub for filepath.Glob
+func (st *Stub) Glob(pattern string) ([]string, error) {
+	return st.fs.Glob(pattern)
+}
+
 // WriteFile is a stub for ioutil.WriteFile
 func (st *Stub) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	if m, ok := st.expected("WriteFile", filename, data, perm); ok {
+		return m.err
+	}
 	return st.fs.WriteFile(filename, data, perm)
 }
 
+// Truncate is a stub for os.Truncate
+func (st *Stub) Truncate(name string, size int64) error {
+	if m, ok := st.expected("Truncate", name, size); ok {
+		return m.err
+	}
+	return st.fs.Truncate(name, size)
+}
+
+// Chmod is a stub for os.Chmod
+func (st *Stub) Chmod(name string, mode os.FileMode) error {
+	if m, ok := st.expected("Chmod", name, mode); ok {
+		return m.err
+	}
+	return st.fs.Chmod(name, mode)
+}
+
+// Rename is a stub for os.Rename
+func (st *Stub) Rename(oldpath, newpath string) error {
+	if m, ok := st.expected("Rename", oldpath, st.fs.Resolve(newpath)); ok {
+		return m.err
+	}
+	return st.fs.Rename(oldpath, newpath)
+}
+
+// Open is a stub for os.Open
+func (st *Stub) Open(name string) (file.Handle, error) {
+	if m, ok := st.expected("Open", name); ok {
+		return retFile(m.ret, 0), m.err
+	}
+	return handle(st.fs.Open(name))
+}
+
+// Create is a stub for os.Create
+func (st *Stub) Create(name string) (file.Handle, error) {
+	if m, ok := st.expected("Create", name); ok {
+		return retFile(m.ret, 0), m.err
+	}
+	return handle(st.fs.Create(name))
+}
+
+// OpenFile is a stub for os.OpenFile
+func (st *Stub) OpenFile(name string, flag int, perm os.FileMode) (file.Handle, error) {
+	if m, ok := st.expected("OpenFile", name, flag, perm); ok {
+		return retFile(m.ret, 0), m.err
+	}
+	return handle(st.fs.OpenFile(name, flag, perm))
+}
+
+// Mkdir is a stub for os.Mkdir
+func (st *Stub) Mkdir(name string, perm os.FileMode) error {
+	if m, ok := st.expected("Mkdir", name, perm); ok {
+		return m.err
+	}
+	return st.fs.Mkdir(name, perm)
+}
+
+// MkdirAll is a stub for os.MkdirAll
+func (st *Stub) MkdirAll(name string, perm os.FileMode) error {
+	if m, ok := st.expected("MkdirAll", name, perm); ok {
+		return m.err
+	}
+	return st.fs.MkdirAll(name, perm)
+}
+
+// Remove is a stub for os.Remove
+func (st *Stub) Remove(name string) error {
+	if m, ok := st.expected("Remove", name); ok {
+		return m.err
+	}
+	return st.fs.Remove(name)
+}
+
+// RemoveAll is a stub for os.RemoveAll
+func (st *Stub) RemoveAll(name string) error {
+	if m, ok := st.expected("RemoveAll", name); ok {
+		return m.err
+	}
+	return st.fs.RemoveAll(name)
+}
+
+// TempDir is a stub for os.TempDir
+func (st *Stub) TempDir() string {
+	return st.fs.TempDir()
+}
+
+// CreateTemp is a stub for os.CreateTemp
+func (st *Stub) CreateTemp(dir, pattern string) (file.Handle, error) {
+	return handle(st.fs.CreateTemp(dir, pattern))
+}
+
+// MkdirTemp is a stub for os.MkdirTemp
+func (st *Stub) MkdirTemp(dir, pattern string) (string, error) {
+	return st.fs.MkdirTemp(dir, pattern)
+}
+
 // Abs is a stub for filepath.Abs
 func (st *Stub) Abs(p string) (string, error) {
 	return st.fs.Abs(p)
 }
+
+// Chdir is a stub for os.Chdir
+func (st *Stub) Chdir(dir string) error {
+	return st.fs.Chdir(dir)
+}
+
+// Getwd is a stub for os.Getwd
+func (st *Stub) Getwd() (string, error) {
+	return st.fs.Getwd()
+}
@@ -2,7 +2,10 @@ package stub
 
 import (
 	"errors"
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/shebang-go/fsmocker/file"
 	"github.com/shebang-go/fsmocker/testdouble"
@@ -144,3 +147,59 @@ func TestStub_Options(t *testing.T) {
 		})
 	}
 }
+
+func TestNewStub_optionsAfterPaths(t *testing.T) {
+	clock := file.NewFakeClock(time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC))
+	configure := func(stub *Stub) {
+		stub.Config("/src/main.go").Mode(0600)
+	}
+	st := NewStub([]string{"/src[main.go(mtime=-2h)]"}, configure, WithClock(clock))
+	fi, err := st.Stat("/src/main.go")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode())
+	assert.Equal(t, clock.Now().Add(-2*time.Hour), fi.ModTime())
+}
+
+func TestStub_WriteFile(t *testing.T) {
+	st := NewStub([]string{"/home/john"}).(*Stub)
+	assert.NoError(t, st.WriteFile("/home/john/file1", []byte("data"), 0644))
+	data, err := st.ReadFile("/home/john/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	err = st.WriteFile("/missing/file1", []byte("data"), 0644)
+	assert.True(t, errors.Is(err, syscall.ENOENT), "got %v", err)
+	assert.Nil(t, st.FileInfo("/missing/file1"))
+}
+
+func TestStub_Open(t *testing.T) {
+	st := NewStub([]string{"/home/john[file1(data=test)]"})
+	f, err := st.Open("/home/john/file1")
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/john/file1", f.Name())
+	assert.NoError(t, f.Close())
+
+	f, err = st.Open("/home/john/missing")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.True(t, f == nil, "got %#v", f)
+}
+
+func TestStub_Coverage(t *testing.T) {
+	var st *Stub
+	t.Run("test", func(t *testing.T) {
+		st = NewStub([]string{"/etc[app.yaml, old.yaml]"}, WithCoverage(t, file.CoverageReport)).(*Stub)
+		st.ReadFile("/etc/app.yaml")
+	})
+	assert.Equal(t, []string{"/etc/old.yaml"}, st.Coverage().Unused())
+}
+
+func TestStub_logCaller(t *testing.T) {
+	mem := testdouble.NewMemorySink()
+	st := NewStub([]string{"/etc[app.yaml]"}, WithGlobalOptions(testdouble.WithSink(mem))).(*Stub)
+	st.ReadFile("/etc/app.yaml")
+	entries := mem.Entries()
+	assert.NotEmpty(t, entries)
+	for _, e := range entries {
+		assert.Regexp(t, `^stub_test\.go:\d+$`, e.Caller)
+	}
+}
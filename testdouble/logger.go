@@ -1,37 +1,269 @@
 package testdouble
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	// LevelDebug is used for details of operations
+	LevelDebug Level = iota
+	// LevelInfo is used for successful operations (default)
+	LevelInfo
+	// LevelWarn is used for unexpected but handled conditions
+	LevelWarn
+	// LevelError is used for failed operations
+	LevelError
+)
+
+var levelNames = map[Level]string{LevelDebug: "DEBUG", LevelInfo: "INFO", LevelWarn: "WARN", LevelError: "ERROR"}
+
+func (l Level) String() string {
+	if s, ok := levelNames[l]; ok {
+		return s
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// Entry is a log entry.
+type Entry struct {
+	Time   time.Time
+	Level  Level
+	Module string
+	Op     string
+	Path   string
+	Msg    string
+	Err    error
+	// Caller is the file:line of the call outside of fsmocker which caused
+	// the entry, empty if unknown
+	Caller string
+}
+
+// String formats the entry as a line of text.
+func (e Entry) String() string {
+	return fmt.Sprintf("|%-5s|%-10s|%-30s|%-20v|%-s", e.Level, e.Op, e.Msg, e.Err, e.Path)
+}
+
+// Sink receives log entries.
+type Sink interface {
+	Write(e Entry)
+}
+
+// SinkFunc is a function used as Sink.
+type SinkFunc func(e Entry)
+
+// Write calls f(e).
+func (f SinkFunc) Write(e Entry) {
+	f(e)
+}
+
+type tbSink struct {
+	tb testing.TB
+}
+
+// TBSink returns a sink logging with tb.Log. Lines are prefixed with the
+// caller of the entry, since tb.Log reports a location inside fsmocker.
+func TBSink(tb testing.TB) Sink {
+	return &tbSink{tb: tb}
+}
+
+func (s *tbSink) Write(e Entry) {
+	s.tb.Helper()
+	if e.Caller != "" {
+		s.tb.Log(e.Caller + ": " + e.String())
+		return
+	}
+	s.tb.Log(e.String())
+}
+
+// Format is the output format of a WriterSink.
+type Format int
+
+const (
+	// Text writes entries as lines of text (see Entry.String)
+	Text Format = iota
+	// JSON writes entries as JSON lines
+	JSON
+)
+
+type writerSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format Format
+}
+
+// WriterSink returns a sink writing entries to w in format.
+func WriterSink(w io.Writer, format Format) Sink {
+	return &writerSink{w: w, format: format}
+}
+
+type jsonEntry struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Module string    `json:"module,omitempty"`
+	Op     string    `json:"op,omitempty"`
+	Path   string    `json:"path,omitempty"`
+	Msg    string    `json:"msg"`
+	Err    string    `json:"err,omitempty"`
+	Caller string    `json:"caller,omitempty"`
+}
+
+func (s *writerSink) Write(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.format == JSON {
+		je := jsonEntry{Time: e.Time, Level: e.Level.String(), Module: e.Module, Op: e.Op, Path: e.Path, Msg: e.Msg, Caller: e.Caller}
+		if e.Err != nil {
+			je.Err = e.Err.Error()
+		}
+		_ = json.NewEncoder(s.w).Encode(je)
+		return
+	}
+	fmt.Fprintln(s.w, e.String())
+}
+
+// MemorySink keeps log entries in memory.
+type MemorySink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemorySink creates a new in-memory sink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Write appends e to the entries.
+func (s *MemorySink) Write(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+// Entries returns the entries in the order they were written.
+func (s *MemorySink) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry{}, s.entries...)
+}
+
+// Reset removes all entries.
+func (s *MemorySink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+}
+
+// Filter returns a sink passing entries to s if keep returns true.
+func Filter(s Sink, keep func(e Entry) bool) Sink {
+	return SinkFunc(func(e Entry) {
+		if keep(e) {
+			s.Write(e)
+		}
+	})
+}
+
+// MinLevel returns a sink passing entries with a level of at least l to s.
+func MinLevel(s Sink, l Level) Sink {
+	return Filter(s, func(e Entry) bool { return e.Level >= l })
+}
+
+// Ops returns a sink passing entries of the operations ops to s.
+func Ops(s Sink, ops ...string) Sink {
+	return Filter(s, func(e Entry) bool {
+		for _, op := range ops {
+			if e.Op == op {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Paths returns a sink passing entries with a path matching one of patterns
+// (see filepath.Match) to s.
+func Paths(s Sink, patterns ...string) Sink {
+	return Filter(s, func(e Entry) bool {
+		for _, p := range patterns {
+			if ok, _ := filepath.Match(p, e.Path); ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 type logger struct {
-	t *testing.T
+	mu    sync.Mutex
+	sinks []Sink
 }
 
+// CreateLogger creates a logger writing to t.
 func CreateLogger(t *testing.T) *logger {
-	l := &logger{
-		t: t,
+	l := &logger{}
+	if t != nil {
+		l.sinks = append(l.sinks, TBSink(t))
 	}
 	return l
 }
 
+func (l *logger) addSinks(sinks ...Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, sinks...)
+}
+
+// hasTB returns true if the logger has a TBSink logging to tb.
+func (l *logger) hasTB(tb testing.TB) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		if tbs, ok := s.(*tbSink); ok && tbs.tb == tb {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *logger) getSinks() []Sink {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Sink{}, l.sinks...)
+}
+
 type StructuredLogging interface {
 	Module(string) *structuredLogging
 	Path(string) *structuredLogging
 	Operation(string) *structuredLogging
 	Error(error) *structuredLogging
+	Level(Level) *structuredLogging
 	Msg(format string, args ...interface{}) *structuredLogging
 }
 
 type structuredLogging struct {
+	logger *logger
+	level  Level
 	module string
 	op     string
 	path   string
 	format string
 	err    error
 	msg    string
-	t      *testing.T
 }
 
 func (sl *structuredLogging) Module(v string) *structuredLogging {
@@ -53,14 +285,54 @@ func (sl *structuredLogging) Operation(v string) *structuredLogging {
 	return sl
 }
 
+// Level sets the level of the entry (default: LevelInfo).
+func (sl *structuredLogging) Level(l Level) *structuredLogging {
+	sl.level = l
+	return sl
+}
+
 func (sl *structuredLogging) Msg(format string, args ...interface{}) *structuredLogging {
 	sl.msg = fmt.Sprintf(format, args...)
 	return sl
 }
 
+// Done writes the entry to the sinks of the logger.
 func (sl *structuredLogging) Done() {
-	m := fmt.Sprintf("|%-10s|%-30s|%-20v|%-s", sl.op, sl.msg, sl.err, sl.path)
-	if sl.t != nil {
-		sl.t.Log(m)
+	sinks := sl.logger.getSinks()
+	if len(sinks) == 0 {
+		return
+	}
+	e := Entry{
+		Time:   time.Now(),
+		Level:  sl.level,
+		Module: sl.module,
+		Op:     sl.op,
+		Path:   sl.path,
+		Msg:    sl.msg,
+		Err:    sl.err,
+		Caller: caller(),
+	}
+	for _, s := range sinks {
+		s.Write(e)
+	}
+}
+
+// modulePath is the import path of fsmocker.
+var modulePath = path.Dir(reflect.TypeOf(logger{}).PkgPath())
+
+// caller returns the file:line of the first caller outside of fsmocker.
+// Tests of fsmocker count as callers.
+func caller() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, modulePath+"/") && !strings.HasPrefix(f.Function, modulePath+".") ||
+			strings.HasSuffix(f.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+		}
+		if !more {
+			return ""
+		}
 	}
 }
@@ -1 +1,116 @@
 package testdouble
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/shebang-go/fsmocker/internal/faket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestDouble_Log(t *testing.T) {
+	mem := NewMemorySink()
+	td := NewTestDouble(WithLogging(t), WithSink(mem)).(*TestDouble)
+	err := errors.New("test")
+	td.Log("read %d bytes", 3).Path("/a").Operation("ReadFile").Done()
+	td.Log("return error").Level(LevelError).Path("/b").Operation("Stat").Error(err).Done()
+
+	entries := mem.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "read 3 bytes", entries[0].Msg)
+	assert.Equal(t, LevelInfo, entries[0].Level)
+	assert.Equal(t, "/b", entries[1].Path)
+	assert.Equal(t, err, entries[1].Err)
+	assert.False(t, entries[1].Time.IsZero())
+
+	mem.Reset()
+	assert.Empty(t, mem.Entries())
+}
+
+func TestTestDouble_LogWithoutSinks(t *testing.T) {
+	td := NewTestDouble().(*TestDouble)
+	assert.NotPanics(t, func() { td.Log("nothing").Done() })
+}
+
+func TestWriterSink(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "text",
+			format: Text,
+			want:   "|ERROR|Stat      |return error                  |test                |/a\n",
+		},
+		{
+			name:   "json",
+			format: JSON,
+			want:   `{"time":"0001-01-01T00:00:00Z","level":"ERROR","op":"Stat","path":"/a","msg":"return error","err":"test"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			WriterSink(buf, tt.format).Write(Entry{Level: LevelError, Op: "Stat", Path: "/a", Msg: "return error", Err: errors.New("test")})
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	entries := []Entry{
+		{Level: LevelDebug, Op: "Stat", Path: "/a/b"},
+		{Level: LevelInfo, Op: "ReadFile", Path: "/a/c"},
+		{Level: LevelError, Op: "Stat", Path: "/d"},
+	}
+	tests := []struct {
+		name   string
+		filter func(s Sink) Sink
+		want   []string
+	}{
+		{name: "minLevel", filter: func(s Sink) Sink { return MinLevel(s, LevelInfo) }, want: []string{"/a/c", "/d"}},
+		{name: "ops", filter: func(s Sink) Sink { return Ops(s, "Stat") }, want: []string{"/a/b", "/d"}},
+		{name: "paths", filter: func(s Sink) Sink { return Paths(s, "/a/*") }, want: []string{"/a/b", "/a/c"}},
+		{name: "combined", filter: func(s Sink) Sink { return Ops(MinLevel(s, LevelError), "Stat") }, want: []string{"/d"}},
+		{name: "custom", filter: func(s Sink) Sink {
+			return Filter(s, func(e Entry) bool { return strings.HasSuffix(e.Path, "c") })
+		}, want: []string{"/a/c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := NewMemorySink()
+			s := tt.filter(mem)
+			for _, e := range entries {
+				s.Write(e)
+			}
+			got := []string{}
+			for _, e := range mem.Entries() {
+				got = append(got, e.Path)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTBSink_caller(t *testing.T) {
+	tb := &faket.T{}
+	td := NewTestDouble(WithSink(TBSink(tb))).(*TestDouble)
+	_, _, line, _ := runtime.Caller(0)
+	td.Log("read").Operation("ReadFile").Done()
+	assert.Len(t, tb.Logs(), 1)
+	want := fmt.Sprintf("logger_test.go:%d: |INFO |ReadFile", line+1)
+	assert.True(t, strings.HasPrefix(tb.Logs()[0], want), tb.Logs()[0])
+}
+
+func TestTestDouble_EnableLogging(t *testing.T) {
+	mem := NewMemorySink()
+	td := NewTestDouble(WithSink(mem)).(*TestDouble)
+	td.EnableLogging(t)
+	td.EnableLogging(t)
+	assert.Len(t, td.logger.getSinks(), 2)
+}
@@ -0,0 +1,97 @@
+package testdouble
+
+import (
+	"sync"
+	"time"
+)
+
+// Args holds the arguments of a recorded call (ex: perm, flag, size).
+type Args map[string]interface{}
+
+// Call is a recorded call of a stubbed operation.
+type Call struct {
+	// Seq is the sequence number of the call starting with 1
+	Seq uint64
+	// Op is the name of the operation (ex: ReadFile)
+	Op string
+	// Path is the resolved path
+	Path string
+	// Args holds further arguments
+	Args Args
+	// Err is the returned error
+	Err error
+	// Time is the time of the call
+	Time time.Time
+	// Duration is the time the call took
+	Duration time.Duration
+}
+
+type spy struct {
+	mu    sync.Mutex
+	seq   uint64
+	calls []Call
+}
+
+var spyMu sync.Mutex
+
+func (td *TestDouble) getSpy() *spy {
+	spyMu.Lock()
+	defer spyMu.Unlock()
+	if td.spy == nil {
+		td.spy = &spy{}
+	}
+	return td.spy
+}
+
+// Record records a call and returns it with its sequence number set.
+func (td *TestDouble) Record(c Call) Call {
+	s := td.getSpy()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	c.Seq = s.seq
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+	s.calls = append(s.calls, c)
+	return c
+}
+
+// Calls returns all recorded calls in the order they were made.
+func (td *TestDouble) Calls() []Call {
+	s := td.getSpy()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call{}, s.calls...)
+}
+
+// CallsTo returns all recorded calls of op.
+func (td *TestDouble) CallsTo(op string) []Call {
+	retval := []Call{}
+	for _, c := range td.Calls() {
+		if c.Op == op {
+			retval = append(retval, c)
+		}
+	}
+	return retval
+}
+
+// CallCount returns the number of calls of op for path. An empty op or path
+// matches any op or path.
+func (td *TestDouble) CallCount(op string, path string) int {
+	n := 0
+	for _, c := range td.Calls() {
+		if (op == "" || c.Op == op) && (path == "" || c.Path == path) {
+			n++
+		}
+	}
+	return n
+}
+
+// ResetCalls removes all recorded calls.
+func (td *TestDouble) ResetCalls() {
+	s := td.getSpy()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
@@ -0,0 +1,59 @@
+package testdouble
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestDouble_Record(t *testing.T) {
+	td := NewTestDouble().(*TestDouble)
+	err := errors.New("test")
+	td.Record(Call{Op: "Stat", Path: "/a"})
+	td.Record(Call{Op: "ReadFile", Path: "/a", Err: err})
+	td.Record(Call{Op: "ReadFile", Path: "/b", Args: Args{"size": 3}})
+
+	calls := td.Calls()
+	assert.Len(t, calls, 3)
+	for i, c := range calls {
+		assert.Equal(t, uint64(i+1), c.Seq)
+		assert.False(t, c.Time.IsZero())
+	}
+	assert.Equal(t, err, calls[1].Err)
+	assert.Equal(t, 3, calls[2].Args["size"])
+
+	assert.Len(t, td.CallsTo("ReadFile"), 2)
+	assert.Len(t, td.CallsTo("WriteFile"), 0)
+	assert.Equal(t, 1, td.CallCount("ReadFile", "/a"))
+	assert.Equal(t, 2, td.CallCount("", "/a"))
+	assert.Equal(t, 2, td.CallCount("ReadFile", ""))
+
+	td.ResetCalls()
+	assert.Empty(t, td.Calls())
+}
+
+func TestTestDouble_Record_concurrent(t *testing.T) {
+	td := &TestDouble{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				td.Record(Call{Op: "Stat"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	seqs := []int{}
+	for _, c := range td.Calls() {
+		seqs = append(seqs, int(c.Seq))
+	}
+	assert.True(t, sort.IntsAreSorted(seqs))
+	assert.Len(t, seqs, 1000)
+	assert.Equal(t, 1000, seqs[999])
+}
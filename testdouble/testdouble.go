@@ -15,11 +15,17 @@ type OptionData struct {
 type TestDoubler interface {
 	Log(format string, args ...interface{}) *structuredLogging
 	EnableLogging(t *testing.T)
+	Calls() []Call
+	CallsTo(op string) []Call
+	CallCount(op string, path string) int
+	ResetCalls()
 }
 
 type TestDouble struct {
 	OptionData
 	logger *logger
+	// spy records calls (see Record)
+	spy *spy
 }
 
 func NewTestDouble(opts ...Option) TestDoubler {
@@ -39,21 +45,50 @@ func WithError(err error) Option {
 		td.err = err
 	}
 }
+
+// WithLogging is an option to log to t.
 func WithLogging(t *testing.T) Option {
 	return func(td *TestDouble) {
-		td.t = t
-		// td.enableLogging = true
-		td.logger = CreateLogger(t)
+		td.EnableLogging(t)
+	}
+}
+
+// WithSink is an option to log to sinks (see TBSink, WriterSink and
+// MemorySink).
+func WithSink(sinks ...Sink) Option {
+	return func(td *TestDouble) {
+		td.AddSink(sinks...)
 	}
 }
 
+// T returns the test of the test double (see WithT).
+func (td *TestDouble) T() *testing.T {
+	return td.t
+}
+
+// EnableLogging sets the test of the test double and logs to it. Further
+// calls for the same test do not add a sink.
 func (td *TestDouble) EnableLogging(t *testing.T) {
 	td.t = t
+	if td.logger != nil && td.logger.hasTB(t) {
+		return
+	}
+	td.AddSink(TBSink(t))
+}
+
+// AddSink adds sinks to the logger.
+func (td *TestDouble) AddSink(sinks ...Sink) {
+	if td.logger == nil {
+		td.logger = &logger{}
+	}
+	td.logger.addSinks(sinks...)
 }
 
+// Log starts a log entry, which is written to the sinks by Done.
 func (td *TestDouble) Log(format string, args ...interface{}) *structuredLogging {
 	sl := &structuredLogging{
-		t:      td.t,
+		logger: td.logger,
+		level:  LevelInfo,
 		format: format,
 		msg:    fmt.Sprintf(format, args...),
 	}
@@ -0,0 +1,326 @@
+package testdouble
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// syscalls maps operations to the system calls shown in a trace. Other
+// operations are shown in lower case.
+var syscalls = map[string]string{
+	"Stat":         "stat",
+	"Lstat":        "lstat",
+	"Open":         "openat",
+	"Create":       "openat",
+	"OpenFile":     "openat",
+	"Mkdir":        "mkdir",
+	"MkdirAll":     "mkdir",
+	"Remove":       "unlink",
+	"RemoveAll":    "unlinkat",
+	"CreateTemp":   "openat",
+	"MkdirTemp":    "mkdir",
+	"Symlink":      "symlinkat",
+	"Readlink":     "readlinkat",
+	"Walk":         "lstat",
+	"Glob":         "getdents",
+	"Rename":       "rename",
+	"Chmod":        "chmod",
+	"Truncate":     "truncate",
+	"Chdir":        "chdir",
+	"Statfs":       "statfs",
+	"Sync":         "sync",
+	"ReadDir":      "getdents",
+	"File.Read":    "read",
+	"File.Write":   "write",
+	"File.Close":   "close",
+	"File.Sync":    "fsync",
+	"File.Readdir": "getdents",
+}
+
+// steps maps operations made of several system calls to these calls (see
+// formatSteps).
+var steps = map[string][]string{
+	"ReadFile":  {"openat", "read", "close"},
+	"WriteFile": {"openat", "write", "close"},
+}
+
+// openFlags holds the flags of open in the order they are shown.
+var openFlags = []struct {
+	flag int
+	name string
+}{
+	{os.O_CREATE, "O_CREAT"},
+	{os.O_EXCL, "O_EXCL"},
+	{os.O_TRUNC, "O_TRUNC"},
+	{os.O_APPEND, "O_APPEND"},
+	{os.O_SYNC, "O_SYNC"},
+}
+
+// errnos maps errors of package os to errnos.
+var errnos = []struct {
+	err   error
+	errno syscall.Errno
+}{
+	{os.ErrNotExist, syscall.ENOENT},
+	{os.ErrExist, syscall.EEXIST},
+	{os.ErrPermission, syscall.EACCES},
+	{os.ErrInvalid, syscall.EINVAL},
+	{os.ErrClosed, syscall.EBADF},
+}
+
+// errnoNames holds the names of the errnos shown in a trace.
+var errnoNames = map[syscall.Errno]string{
+	syscall.EACCES:    "EACCES",
+	syscall.EAGAIN:    "EAGAIN",
+	syscall.EBADF:     "EBADF",
+	syscall.EBUSY:     "EBUSY",
+	syscall.EDQUOT:    "EDQUOT",
+	syscall.EEXIST:    "EEXIST",
+	syscall.EINTR:     "EINTR",
+	syscall.EINVAL:    "EINVAL",
+	syscall.EIO:       "EIO",
+	syscall.EISDIR:    "EISDIR",
+	syscall.ENOENT:    "ENOENT",
+	syscall.ENOSPC:    "ENOSPC",
+	syscall.ENOTDIR:   "ENOTDIR",
+	syscall.ENOTEMPTY: "ENOTEMPTY",
+	syscall.EPERM:     "EPERM",
+	syscall.EROFS:     "EROFS",
+	syscall.ESTALE:    "ESTALE",
+}
+
+// FormatCall renders c like strace -tt -T, ex:
+//
+//	10:00:00.000000 openat("/etc/x", O_RDONLY) = -1 ENOENT (no such file or directory) <0.000010>
+//
+// Operations made of several system calls (ex: ReadFile) are rendered as
+// one line per system call.
+func FormatCall(c Call) string {
+	if _, ok := steps[c.Op]; ok {
+		return formatSteps(c)
+	}
+	name, ok := syscalls[c.Op]
+	if !ok {
+		name = strings.ToLower(c.Op)
+	}
+	return formatLine(c, name, traceArgs(c), traceResult(c), c.Duration)
+}
+
+func formatLine(c Call, name string, args []string, result string, d time.Duration) string {
+	return fmt.Sprintf("%s %s(%s) = %s <%.6f>",
+		c.Time.Format("15:04:05.000000"), name, strings.Join(args, ", "), result, d.Seconds())
+}
+
+// formatSteps renders the system calls of c, ex: openat, read and close for
+// ReadFile. A failed call ends the lines. Errors of read or write (see
+// os.PathError) are shown for read or write, others for openat. The duration
+// of c is shown for the last line.
+func formatSteps(c Call) string {
+	failed := "openat"
+	var pe *os.PathError
+	if errors.As(c.Err, &pe) && (pe.Op == "read" || pe.Op == "write") {
+		failed = pe.Op
+	}
+	path := fmt.Sprintf("%q", c.Path)
+	lines := []string{}
+	for _, name := range steps[c.Op] {
+		args := []string{path}
+		result := "0"
+		switch name {
+		case "openat":
+			if c.Op == "WriteFile" {
+				args = append(args, formatFlag(os.O_WRONLY|os.O_CREATE|os.O_TRUNC), fmt.Sprintf("%#o", c.Args["perm"]))
+			} else {
+				args = append(args, formatFlag(os.O_RDONLY))
+			}
+		case "read", "write":
+			if v, ok := c.Args["size"]; ok {
+				result = fmt.Sprintf("%v", v)
+			}
+		}
+		if c.Err != nil && name == failed {
+			lines = append(lines, formatLine(c, name, args, "-1 "+formatErr(c.Err), c.Duration))
+			break
+		}
+		d := time.Duration(0)
+		if name == "close" {
+			d = c.Duration
+		}
+		lines = append(lines, formatLine(c, name, args, result, d))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func traceArgs(c Call) []string {
+	retval := []string{}
+	if c.Path != "" {
+		retval = append(retval, fmt.Sprintf("%q", c.Path))
+	}
+	keys := []string{}
+	for k := range c.Args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := c.Args[k]
+		switch k {
+		case "n", "count":
+			// shown as result (see traceResult)
+		case "flag":
+			if flag, ok := v.(int); ok {
+				retval = append(retval, formatFlag(flag))
+			}
+		case "perm", "mode":
+			retval = append(retval, fmt.Sprintf("%#o", v))
+		case "newpath", "dir", "pattern":
+			retval = append(retval, fmt.Sprintf("%q", v))
+		case "len":
+			retval = append(retval, fmt.Sprintf("%v", v))
+		case "size":
+			if c.Op == "Truncate" {
+				retval = append(retval, fmt.Sprintf("%v", v))
+			}
+		default:
+			retval = append(retval, fmt.Sprintf("%s=%v", k, v))
+		}
+	}
+	return retval
+}
+
+func formatFlag(flag int) string {
+	var names []string
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_WRONLY:
+		names = append(names, "O_WRONLY")
+	case os.O_RDWR:
+		names = append(names, "O_RDWR")
+	default:
+		names = append(names, "O_RDONLY")
+	}
+	for _, f := range openFlags {
+		if flag&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+func traceResult(c Call) string {
+	if c.Err != nil {
+		return "-1 " + formatErr(c.Err)
+	}
+	for _, k := range []string{"n", "count", "size"} {
+		if v, ok := c.Args[k]; ok && !(k == "size" && c.Op == "Truncate") {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return "0"
+}
+
+// formatErr renders err as errno name and message (ex: ENOENT (no such file
+// or directory)).
+func formatErr(err error) string {
+	errno := Errno(err)
+	if name, ok := errnoNames[errno]; ok {
+		return fmt.Sprintf("%s (%s)", name, errno.Error())
+	}
+	if errno != 0 {
+		return fmt.Sprintf("errno %d (%s)", int(errno), errno.Error())
+	}
+	return fmt.Sprintf("(%s)", err)
+}
+
+// Errno returns the errno of err. Errors of package os (ex: os.ErrNotExist)
+// are mapped to their errno, 0 is returned for other errors.
+func Errno(err error) syscall.Errno {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		return errno
+	}
+	for _, e := range errnos {
+		if errors.Is(err, e.err) {
+			return e.errno
+		}
+	}
+	return 0
+}
+
+// ErrnoName returns the name of errno (ex: ENOENT) or an empty string if it
+// is unknown.
+func ErrnoName(errno syscall.Errno) string {
+	return errnoNames[errno]
+}
+
+// ParseErrno returns the errno named name (ex: ENOENT).
+func ParseErrno(name string) (syscall.Errno, bool) {
+	for errno, v := range errnoNames {
+		if v == name {
+			return errno, true
+		}
+	}
+	return 0, false
+}
+
+// Trace renders calls like strace, one call per line (see FormatCall).
+func Trace(calls []Call) string {
+	var sb strings.Builder
+	for _, c := range calls {
+		sb.WriteString(FormatCall(c))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Trace renders the recorded calls like strace (see FormatCall).
+func (td *TestDouble) Trace() string {
+	return Trace(td.Calls())
+}
+
+// WriteTrace writes the trace of the recorded calls to w.
+func (td *TestDouble) WriteTrace(w io.Writer) error {
+	_, err := io.WriteString(w, td.Trace())
+	return err
+}
+
+// WithTraceOnFailure is an option to write the trace of the recorded calls
+// to a file in dir when t failed. dir is created if it does not exist. The
+// file is named after the test.
+func WithTraceOnFailure(t testing.TB, dir string) Option {
+	return func(td *TestDouble) {
+		t.Cleanup(func() {
+			if !t.Failed() {
+				return
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Logf("writing trace: %v", err)
+				return
+			}
+			name := filepath.Join(dir, traceFileName(t.Name()))
+			if err := ioutil.WriteFile(name, []byte(td.Trace()), 0644); err != nil {
+				t.Logf("writing trace: %v", err)
+				return
+			}
+			t.Logf("trace written to %s", name)
+		})
+	}
+}
+
+// traceFileName returns the name of the trace file of test.
+func traceFileName(test string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', ' ':
+			return '_'
+		}
+		return r
+	}, test) + ".trace"
+}
@@ -0,0 +1,125 @@
+package testdouble
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var traceTime = time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC)
+
+func TestFormatCall(t *testing.T) {
+	tests := []struct {
+		name string
+		call Call
+		want string
+	}{
+		{
+			name: "openNotExist",
+			call: Call{Op: "Open", Path: "/etc/x", Args: Args{"flag": os.O_RDONLY, "perm": os.FileMode(0)},
+				Err: &os.PathError{Op: "open", Path: "/etc/x", Err: os.ErrNotExist}},
+			want: `10:00:00.000000 openat("/etc/x", O_RDONLY, 0) = -1 ENOENT (no such file or directory) <0.000000>`,
+		},
+		{
+			name: "openCreate",
+			call: Call{Op: "OpenFile", Path: "/a", Args: Args{"flag": os.O_WRONLY | os.O_CREATE | os.O_TRUNC, "perm": os.FileMode(0644)},
+				Duration: 15 * time.Microsecond},
+			want: `10:00:00.000000 openat("/a", O_WRONLY|O_CREAT|O_TRUNC, 0644) = 0 <0.000015>`,
+		},
+		{
+			name: "read",
+			call: Call{Op: "File.Read", Path: "/a", Args: Args{"len": 512, "n": 5}},
+			want: `10:00:00.000000 read("/a", 512) = 5 <0.000000>`,
+		},
+		{
+			name: "rename",
+			call: Call{Op: "Rename", Path: "/a", Args: Args{"newpath": "/b"}, Err: &os.LinkError{Op: "rename", Old: "/a", New: "/b", Err: syscall.EXDEV}},
+			want: `10:00:00.000000 rename("/a", "/b") = -1 errno 18 (invalid cross-device link) <0.000000>`,
+		},
+		{
+			name: "truncate",
+			call: Call{Op: "Truncate", Path: "/a", Args: Args{"size": int64(3)}},
+			want: `10:00:00.000000 truncate("/a", 3) = 0 <0.000000>`,
+		},
+		{
+			name: "readFile",
+			call: Call{Op: "ReadFile", Path: "/a", Args: Args{"size": 5}, Duration: 15 * time.Microsecond},
+			want: `10:00:00.000000 openat("/a", O_RDONLY) = 0 <0.000000>
+10:00:00.000000 read("/a") = 5 <0.000000>
+10:00:00.000000 close("/a") = 0 <0.000015>`,
+		},
+		{
+			name: "readFileOpenError",
+			call: Call{Op: "ReadFile", Path: "/a", Err: &os.PathError{Op: "open", Path: "/a", Err: syscall.ENOENT}},
+			want: `10:00:00.000000 openat("/a", O_RDONLY) = -1 ENOENT (no such file or directory) <0.000000>`,
+		},
+		{
+			name: "writeFileWriteError",
+			call: Call{Op: "WriteFile", Path: "/a", Args: Args{"perm": os.FileMode(0644), "size": 3},
+				Err: &os.PathError{Op: "write", Path: "/a", Err: syscall.ENOSPC}},
+			want: `10:00:00.000000 openat("/a", O_WRONLY|O_CREAT|O_TRUNC, 0644) = 0 <0.000000>
+10:00:00.000000 write("/a") = -1 ENOSPC (no space left on device) <0.000000>`,
+		},
+		{
+			name: "symlink",
+			call: Call{Op: "Symlink", Path: "/a", Args: Args{"newpath": "/b"}},
+			want: `10:00:00.000000 symlinkat("/a", "/b") = 0 <0.000000>`,
+		},
+		{
+			name: "walk",
+			call: Call{Op: "Walk", Path: "/a"},
+			want: `10:00:00.000000 lstat("/a") = 0 <0.000000>`,
+		},
+		{
+			name: "otherError",
+			call: Call{Op: "Fault", Path: "/a", Args: Args{"rule": "r1"}, Err: errors.New("bad")},
+			want: `10:00:00.000000 fault("/a", rule=r1) = -1 (bad) <0.000000>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.call.Time = traceTime
+			assert.Equal(t, tt.want, FormatCall(tt.call))
+		})
+	}
+}
+
+// failedTB is a test which failed.
+type failedTB struct {
+	testing.TB
+	failed  bool
+	cleanup func()
+	logs    []string
+}
+
+func (tb *failedTB) Name() string                       { return "TestX/sub case" }
+func (tb *failedTB) Failed() bool                       { return tb.failed }
+func (tb *failedTB) Cleanup(f func())                   { tb.cleanup = f }
+func (tb *failedTB) Logf(f string, args ...interface{}) { tb.logs = append(tb.logs, f) }
+
+func TestWithTraceOnFailure(t *testing.T) {
+	for _, failed := range []bool{false, true} {
+		tmp, err := ioutil.TempDir("", "trace")
+		assert.NoError(t, err)
+		defer os.RemoveAll(tmp)
+		dir := filepath.Join(tmp, "traces")
+		tb := &failedTB{failed: failed}
+		td := NewTestDouble(WithTraceOnFailure(tb, dir)).(*TestDouble)
+		td.Record(Call{Op: "Stat", Path: "/a", Time: traceTime})
+		tb.cleanup()
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "TestX_sub_case.trace"))
+		if failed {
+			assert.NoError(t, err)
+			assert.Equal(t, "10:00:00.000000 stat(\"/a\") = 0 <0.000000>\n", string(data))
+		} else {
+			assert.True(t, os.IsNotExist(err))
+		}
+	}
+}